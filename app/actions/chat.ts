@@ -137,7 +137,7 @@ async function getCoursesByQuarter(quarter: number, limit = MAX_ITEMS) {
     );
     if (!response.ok) throw new Error("Failed to fetch courses by quarter");
     const data = await response.json();
-    return simplifyCoursesData(data);
+    return simplifyCoursesData(data.items);
   } catch (error) {
     console.error("Error fetching courses by quarter:", error);
     return [];
@@ -154,7 +154,7 @@ async function getCoursesBySubject(subject: string, limit = MAX_ITEMS) {
     );
     if (!response.ok) throw new Error("Failed to fetch courses by subject");
     const data = await response.json();
-    return simplifyCoursesData(data);
+    return simplifyCoursesData(data.items);
   } catch (error) {
     console.error("Error fetching courses by subject:", error);
     return [];
@@ -171,7 +171,7 @@ async function getCoursesByKey(key: string) {
     );
     if (!response.ok) throw new Error("Failed to fetch courses by key");
     const data = await response.json();
-    return simplifyCoursesData(data);
+    return simplifyCoursesData(data.items);
   } catch (error) {
     console.error("Error fetching courses by key:", error);
     return [];
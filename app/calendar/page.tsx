@@ -34,7 +34,7 @@ export default function CoursePlanner() {
             try {
                 const response = await fetch("http://localhost:8080/api/quarters")
                 if (!response.ok) throw new Error("Failed to fetch quarters")
-                const data = await response.json()
+                const data: number[] = (await response.json()).items.map((quarter: { code: number }) => quarter.code)
                 setQuarters(data)
 
                 // Auto-select the first quarter
@@ -10,11 +10,19 @@ import (
 	"strings"
 
 	"github.com/nynniaw12/ieee-planner/db"
+	"github.com/nynniaw12/ieee-planner/page"
 	"github.com/nynniaw12/ieee-planner/scraper"
+	"github.com/nynniaw12/ieee-planner/term"
 )
 
 func GetAvailableQuartersHandler(db *sql.DB) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
+        params, err := page.ParseParams(r.URL.Query(), page.Names(scraper.QUARTER_SORTS), "-code")
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
 		// query distinct quarters
         query := `SELECT DISTINCT quarter FROM courses ORDER BY quarter DESC`
 
@@ -25,14 +33,14 @@ func GetAvailableQuartersHandler(db *sql.DB) http.HandlerFunc {
         }
         defer rows.Close()
 
-        var quarters []int
+        quarters := make([]term.Info, 0)
         for rows.Next() {
             var quarter int
             if err := rows.Scan(&quarter); err != nil {
                 http.Error(w, fmt.Sprintf("Error scanning quarter: %v", err), http.StatusInternalServerError)
                 return
             }
-            quarters = append(quarters, quarter)
+            quarters = append(quarters, term.Of(quarter).Info())
         }
 
         if err := rows.Err(); err != nil {
@@ -40,8 +48,10 @@ func GetAvailableQuartersHandler(db *sql.DB) http.HandlerFunc {
             return
         }
 
+        page.Sort(quarters, params, scraper.QUARTER_SORTS)
+
         w.Header().Set("Content-Type", "application/json")
-        if err := json.NewEncoder(w).Encode(quarters); err != nil {
+        if err := json.NewEncoder(w).Encode(page.Slice(quarters, params)); err != nil {
             http.Error(w, fmt.Sprintf("Error encoding quarters: %v", err), http.StatusInternalServerError)
             return
         }
@@ -63,6 +73,12 @@ func GetCoursesByQuarterHandler(db *sql.DB) http.HandlerFunc {
             return
         }
 
+        params, err := page.ParseParams(r.URL.Query(), page.Names(scraper.SECTION_SORTS), "")
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
         // Query for courses by quarter
         query := `SELECT id, title, course_number, topic, overview, url, section, subject, school, quarter 
                  FROM courses WHERE quarter = $1`
@@ -75,7 +91,7 @@ func GetCoursesByQuarterHandler(db *sql.DB) http.HandlerFunc {
         defer rows.Close()
 
         // Collect courses into a slice
-        var courses []*scraper.Course
+        courses := make([]*scraper.Course, 0)
         courseIDs := make(map[int]*scraper.Course)
 
         for rows.Next() {
@@ -114,10 +130,11 @@ func GetCoursesByQuarterHandler(db *sql.DB) http.HandlerFunc {
             }
             course.MeetingTimes = meetingTimes
         }
+        page.Sort(courses, params, scraper.SECTION_SORTS)
 
-        // Return courses as JSON
+        // Return a page of courses as JSON
         w.Header().Set("Content-Type", "application/json")
-        if err := json.NewEncoder(w).Encode(courses); err != nil {
+        if err := json.NewEncoder(w).Encode(page.Slice(courses, params)); err != nil {
             http.Error(w, fmt.Sprintf("Error encoding courses: %v", err), http.StatusInternalServerError)
             return
         }
@@ -189,6 +206,12 @@ func GetCoursesBySubjectHandler(db *sql.DB) http.HandlerFunc {
             return
         }
 
+        params, err := page.ParseParams(r.URL.Query(), page.Names(scraper.SUBJECT_SORTS), "number")
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
         // Query for courses by subject
         query := `SELECT DISTINCT title, course_number, topic, overview, array_agg(quarter) as quarters
                  FROM courses 
@@ -204,18 +227,10 @@ func GetCoursesBySubjectHandler(db *sql.DB) http.HandlerFunc {
         defer rows.Close()
 
         // Collect courses into a slice
-        type CourseBySubject struct {
-            Title    string `json:"title"`
-            Number   string `json:"number"`
-            Topic    string `json:"topic"`
-            Overview string `json:"overview"`
-            Quarters []int  `json:"quarters"`
-        }
-
-        var coursesBySubject []*CourseBySubject
+        coursesBySubject := make([]*scraper.CourseBySubject, 0)
         
         for rows.Next() {
-            course := &CourseBySubject{}
+            course := &scraper.CourseBySubject{}
             var quartersArray []int
             
             if err := rows.Scan(&course.Title, &course.Number, &course.Topic, 
@@ -234,9 +249,11 @@ func GetCoursesBySubjectHandler(db *sql.DB) http.HandlerFunc {
             return
         }
 
-        // Return courses as JSON
+        page.Sort(coursesBySubject, params, scraper.SUBJECT_SORTS)
+
+        // Return a page of courses as JSON
         w.Header().Set("Content-Type", "application/json")
-        if err := json.NewEncoder(w).Encode(coursesBySubject); err != nil {
+        if err := json.NewEncoder(w).Encode(page.Slice(coursesBySubject, params)); err != nil {
             http.Error(w, fmt.Sprintf("Error encoding courses: %v", err), http.StatusInternalServerError)
             return
         }
@@ -245,13 +262,19 @@ func GetCoursesBySubjectHandler(db *sql.DB) http.HandlerFunc {
 
 func GetCoursesByKeyHandler(db *sql.DB) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        // Get key parameter from query string
-        key := r.URL.Query().Get("key")
+        // Get key parameter from query string, "cs 211" becomes "COMP_SCI 211"
+        key := scraper.NormalizeKey(r.URL.Query().Get("key"))
         if key == "" {
             http.Error(w, "Key parameter is required", http.StatusBadRequest)
             return
         }
 
+        params, err := page.ParseParams(r.URL.Query(), page.Names(scraper.SECTION_SORTS), "-quarter")
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
         // Parse key to get subject and number parts
         parts := strings.Split(key, " ")
         if len(parts) != 2 {
@@ -275,7 +298,7 @@ func GetCoursesByKeyHandler(db *sql.DB) http.HandlerFunc {
         defer rows.Close()
 
         // Process results similar to GetCoursesByQuarterHandler
-        var courses []*scraper.Course
+        courses := make([]*scraper.Course, 0)
         courseIDs := make(map[int]*scraper.Course)
 
         for rows.Next() {
@@ -298,17 +321,18 @@ func GetCoursesByKeyHandler(db *sql.DB) http.HandlerFunc {
             course.Instructors, _ = getInstructorsForCourse(db, id)
             course.MeetingTimes, _ = getMeetingTimesForCourse(db, id)
         }
+        page.Sort(courses, params, scraper.SECTION_SORTS)
 
-        // Return courses as JSON
+        // Return a page of courses as JSON
         w.Header().Set("Content-Type", "application/json")
-        if err := json.NewEncoder(w).Encode(courses); err != nil {
+        if err := json.NewEncoder(w).Encode(page.Slice(courses, params)); err != nil {
             http.Error(w, fmt.Sprintf("Error encoding courses: %v", err), http.StatusInternalServerError)
             return
         }
     }
 }
 
-// GetMajorRequirementsHandler handles requests for major requirements
+// GetMajorRequirementsHandler handles requests for major requirements, year defaults to the most recent catalog year
 func GetMajorRequirementsHandler(database *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		major := r.URL.Query().Get("major")
@@ -317,7 +341,17 @@ func GetMajorRequirementsHandler(database *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		reqs, err := db.GetMajorReqsFromDatabase(database, major)
+		year := 0
+		if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+			var err error
+			year, err = strconv.Atoi(yearStr)
+			if err != nil {
+				http.Error(w, "Invalid year format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		reqs, err := db.GetMajorReqsFromDatabase(database, major, year)
 		if err != nil {
 			if strings.Contains(err.Error(), "not found") {
 				http.Error(w, "Major not found", http.StatusNotFound)
@@ -346,4 +380,25 @@ func GetAvailableMajorsHandler(database *sql.DB) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(majors)
 	}
-}
\ No newline at end of file
+}
+
+// GetCatalogYearsHandler returns the catalog years a major has requirements for
+func GetCatalogYearsHandler(database *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		major := r.URL.Query().Get("major")
+		if major == "" {
+			http.Error(w, "Major parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		years, err := db.GetMajorYearsFromDatabase(database, major)
+		if err != nil {
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			log.Printf("Error retrieving catalog years: %v", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(years)
+	}
+}
@@ -0,0 +1,168 @@
+package audit
+
+// a slot is one option of a generic block, it is filled by exactly one of its
+// completed bundles. single course bundles are matched with augmenting paths,
+// bundles with several courses are not a matching problem so every way of
+// picking them is searched and the single course slots are matched for each
+type slot struct {
+	single []string
+	multi  [][]string
+}
+
+// upper bound on the number of multi course combinations tried before the best
+// one found so far is used
+const SEARCH_BUDGET = 1 << 14
+
+type assigner struct {
+	slots    []slot
+	capacity map[string]int
+	load     map[string]int
+	budget   int
+
+	chosen    [][]string
+	best      [][]string
+	bestScore int
+	bestUses  int
+}
+
+// assign returns the courses applied to every slot, nil for slots left unfilled,
+// maximizing the number of filled slots while no course is used more than maxUses times
+func assign(slots []slot, completed []string, maxUses int) [][]string {
+	a := &assigner{
+		slots:     slots,
+		capacity:  make(map[string]int, len(completed)),
+		load:      make(map[string]int, len(completed)),
+		budget:    SEARCH_BUDGET,
+		chosen:    make([][]string, len(slots)),
+		bestScore: -1,
+	}
+	for _, key := range completed {
+		a.capacity[key] = maxUses
+	}
+
+	a.search(0, 0)
+	return a.best
+}
+
+func (a *assigner) search(i, score int) {
+	if a.budget <= 0 {
+		return
+	}
+
+	// even if every remaining slot were filled we could not beat the best
+	if a.bestScore >= 0 && score+a.countOpen(i) < a.bestScore {
+		return
+	}
+
+	if i == len(a.slots) {
+		a.budget--
+		a.evaluate(score)
+		return
+	}
+
+	for _, bundle := range a.slots[i].multi {
+		if !a.fits(bundle) {
+			continue
+		}
+		a.take(bundle, 1)
+		a.chosen[i] = bundle
+		a.search(i+1, score+1)
+		a.chosen[i] = nil
+		a.take(bundle, -1)
+	}
+
+	// leave the slot to the single course matching
+	a.search(i+1, score)
+}
+
+// slots from i onwards that could still be filled plus the ones already picked
+// that the matching may fill, used as an optimistic bound
+func (a *assigner) countOpen(i int) int {
+	open := 0
+	for j, s := range a.slots {
+		if j >= i && (len(s.single) > 0 || len(s.multi) > 0) {
+			open++
+		} else if j < i && a.chosen[j] == nil && len(s.single) > 0 {
+			open++
+		}
+	}
+	return open
+}
+
+func (a *assigner) fits(bundle []string) bool {
+	for _, key := range bundle {
+		if a.load[key]+1 > a.capacity[key] {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *assigner) take(bundle []string, delta int) {
+	for _, key := range bundle {
+		a.load[key] += delta
+	}
+}
+
+func (a *assigner) evaluate(score int) {
+	matched := a.match()
+
+	result := make([][]string, len(a.slots))
+	uses := 0
+	for i := range a.slots {
+		if a.chosen[i] != nil {
+			result[i] = a.chosen[i]
+		} else if course, ok := matched[i]; ok {
+			result[i] = []string{course}
+			score++
+		}
+		uses += len(result[i])
+	}
+
+	// prefer more filled slots, then fewer course uses so bundles do not eat singles
+	if score > a.bestScore || (score == a.bestScore && uses < a.bestUses) {
+		a.best = result
+		a.bestScore = score
+		a.bestUses = uses
+	}
+}
+
+// bipartite matching between the slots without a multi course bundle and the
+// courses, each course can be matched up to its remaining capacity
+func (a *assigner) match() map[int]string {
+	matched := make(map[int]string)
+	holders := make(map[string][]int)
+
+	var augment func(s int, visited map[string]bool) bool
+	augment = func(s int, visited map[string]bool) bool {
+		for _, course := range a.slots[s].single {
+			if visited[course] {
+				continue
+			}
+			visited[course] = true
+
+			if a.load[course]+len(holders[course]) < a.capacity[course] {
+				holders[course] = append(holders[course], s)
+				matched[s] = course
+				return true
+			}
+
+			for h, other := range holders[course] {
+				if augment(other, visited) {
+					holders[course][h] = s
+					matched[s] = course
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for i := range a.slots {
+		if a.chosen[i] == nil && len(a.slots[i].single) > 0 {
+			augment(i, make(map[string]bool))
+		}
+	}
+
+	return matched
+}
@@ -0,0 +1,403 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+type AuditRequest struct {
+	Major     string   `json:"major"`
+	Completed []string `json:"completed"`
+	// catalog year to audit against, defaults to the most recent one
+	Year int `json:"year"`
+	// how many requirement slots a single course may fill, defaults to 1
+	MaxUses int `json:"maxUses"`
+	// elective pool used for theme blocks, defaults to DEFAULT_THEME
+	Theme string `json:"theme"`
+}
+
+// Courses is used to look up the school of completed courses for pool rules
+type AuditOptions struct {
+	MaxUses int
+	Theme   *ElectivePool
+	Courses *scraper.CoursesStore
+}
+
+type OptionResult struct {
+	Between     []scraper.Requirement `json:"between"`
+	Satisfied   bool                  `json:"satisfied"`
+	SatisfiedBy []string              `json:"satisfiedBy"`
+	Missing     []string              `json:"missing"`
+	// completed courses of the closest bundle that were already applied to other slots
+	UsedElsewhere []string `json:"usedElsewhere"`
+}
+
+type BlockResult struct {
+	Name            string         `json:"name"`
+	RequirementType int            `json:"type"`
+	Satisfied       bool           `json:"satisfied"`
+	Options         []OptionResult `json:"options,omitempty"`
+	NumRequired     int            `json:"numRequired"`
+	NumCompleted    int            `json:"numCompleted"`
+	NumRemaining    int            `json:"numRemaining"`
+	AppliedCourses  []string       `json:"appliedCourses"`
+	// unknown blocks cannot be checked against a course list
+	Verifiable bool `json:"verifiable"`
+	// program the block comes from when auditing a resolved major
+	Source string `json:"source,omitempty"`
+}
+
+// Option is -1 for blocks that only count courses
+type Assignment struct {
+	Course string `json:"course"`
+	Block  string `json:"block"`
+	Option int    `json:"option"`
+}
+
+type AuditReport struct {
+	Major         string        `json:"major"`
+	IsEngineering bool          `json:"isEngineering"`
+	Complete      bool          `json:"complete"`
+	Blocks        []BlockResult `json:"blocks"`
+	NumRequired   int           `json:"numRequired"`
+	NumCompleted  int           `json:"numCompleted"`
+	Assignments   []Assignment  `json:"assignments"`
+	Unused        []string      `json:"unused"`
+}
+
+func NormalizeCourseKey(key string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(key), " "))
+}
+
+func completedSet(completed []string) map[string]bool {
+	set := make(map[string]bool, len(completed))
+	for _, key := range completed {
+		key = NormalizeCourseKey(key)
+		if key != "" {
+			set[key] = true
+		}
+	}
+	return set
+}
+
+func normalizeBundle(req scraper.Requirement) []string {
+	keys := make([]string, 0, len(req.Courses))
+	for _, course := range req.Courses {
+		keys = append(keys, NormalizeCourseKey(course))
+	}
+	return keys
+}
+
+// slots for every option of a generic block with the bundles that are fully completed
+func buildSlot(opt scraper.Option, completed map[string]bool) slot {
+	var s slot
+	for _, req := range opt.Between {
+		bundle := normalizeBundle(req)
+		if len(bundle) == 0 {
+			continue
+		}
+
+		complete := true
+		for _, key := range bundle {
+			if !completed[key] {
+				complete = false
+				break
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		if len(bundle) == 1 {
+			s.single = append(s.single, bundle[0])
+		} else {
+			s.multi = append(s.multi, bundle)
+		}
+	}
+	return s
+}
+
+// an unfilled option reports the bundle closest to completion, courses that are
+// completed but already applied elsewhere are listed separately from missing ones
+func explainOption(opt scraper.Option, completed map[string]bool, exhausted func(string) bool) OptionResult {
+	res := OptionResult{
+		Between:       opt.Between,
+		SatisfiedBy:   []string{},
+		Missing:       []string{},
+		UsedElsewhere: []string{},
+	}
+
+	best := -1
+	for _, req := range opt.Between {
+		var missing, elsewhere []string
+		for _, key := range normalizeBundle(req) {
+			if !completed[key] {
+				missing = append(missing, key)
+			} else if exhausted(key) {
+				elsewhere = append(elsewhere, key)
+			}
+		}
+
+		cost := len(missing) + len(elsewhere)
+		if best == -1 || cost < best || (cost == best && len(missing) < len(res.Missing)) {
+			best = cost
+			res.Missing = append([]string{}, missing...)
+			res.UsedElsewhere = append([]string{}, elsewhere...)
+		}
+	}
+	return res
+}
+
+func Audit(mr *scraper.MajorRequirements, completed []string, opts AuditOptions) (*AuditReport, error) {
+	maxUses := max(opts.MaxUses, 1)
+	done := completedSet(completed)
+
+	var keys []string
+	for _, key := range completed {
+		key = NormalizeCourseKey(key)
+		if key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	report := &AuditReport{
+		Major:         mr.Major,
+		IsEngineering: mr.IsEngineering,
+		Blocks:        make([]BlockResult, 0, len(mr.AllRequirements)),
+		Assignments:   []Assignment{},
+		Unused:        []string{},
+	}
+
+	var slots []slot
+	var generics []scraper.GenericRequirements
+	var genericIdx []int
+	var counted []int
+	for _, block := range mr.AllRequirements {
+		switch req := block.(type) {
+		case scraper.GenericRequirements:
+			for _, opt := range req.Requirements {
+				slots = append(slots, buildSlot(opt, done))
+			}
+			generics = append(generics, req)
+			genericIdx = append(genericIdx, len(report.Blocks))
+			report.Blocks = append(report.Blocks, BlockResult{
+				Name:            req.Name,
+				RequirementType: req.GetType(),
+				Options:         make([]OptionResult, len(req.Requirements)),
+				NumRequired:     len(req.Requirements),
+				AppliedCourses:  []string{},
+				Verifiable:      true,
+			})
+		case scraper.ThemeRequirements:
+			counted = append(counted, len(report.Blocks))
+			report.Blocks = append(report.Blocks, countBlock("Theme", req.GetType(), req.NumRequirements))
+		case scraper.UnrestrictedRequirements:
+			counted = append(counted, len(report.Blocks))
+			report.Blocks = append(report.Blocks, countBlock("Unrestricted Electives", req.GetType(), req.NumRequirements))
+		case scraper.UnknownRequirements:
+			counted = append(counted, len(report.Blocks))
+			report.Blocks = append(report.Blocks, countBlock("Unknown", req.GetType(), req.NumRequirements))
+		default:
+			return nil, fmt.Errorf("unsupported requirement block %T", block)
+		}
+	}
+
+	filled := assign(slots, keys, maxUses)
+
+	uses := make(map[string]int)
+	for _, bundle := range filled {
+		for _, key := range bundle {
+			uses[key]++
+		}
+	}
+	exhausted := func(key string) bool { return uses[key] >= maxUses }
+
+	i := 0
+	for g, gr := range generics {
+		br := &report.Blocks[genericIdx[g]]
+		for j, opt := range gr.Requirements {
+			bundle := filled[i]
+			i++
+
+			if bundle == nil {
+				br.Options[j] = explainOption(opt, done, exhausted)
+				continue
+			}
+
+			br.Options[j] = OptionResult{
+				Between:       opt.Between,
+				Satisfied:     true,
+				SatisfiedBy:   bundle,
+				Missing:       []string{},
+				UsedElsewhere: []string{},
+			}
+			br.NumCompleted++
+			br.AppliedCourses = append(br.AppliedCourses, bundle...)
+			for _, key := range bundle {
+				report.Assignments = append(report.Assignments, Assignment{Course: key, Block: br.Name, Option: j})
+			}
+		}
+		br.NumRemaining = br.NumRequired - br.NumCompleted
+		br.Satisfied = br.NumRemaining == 0
+	}
+
+	// courses with uses left go to the elective pools, themes first since
+	// everything that counts for a theme also counts as unrestricted
+	var leftover []string
+	for _, key := range keys {
+		if !exhausted(key) {
+			leftover = append(leftover, key)
+		}
+	}
+
+	theme := opts.Theme
+	if theme == nil {
+		theme, _ = GetElectivePool(DEFAULT_THEME)
+	}
+	unrestricted := UNRESTRICTED_POOL
+
+	for _, kind := range []int{scraper.THEME_REQUIREMENTS, scraper.UNRESTRICTED_REQUIREMENTS} {
+		pool := &unrestricted
+		if kind == scraper.THEME_REQUIREMENTS {
+			pool = theme
+		}
+
+		for _, i := range counted {
+			br := &report.Blocks[i]
+			if br.RequirementType != kind {
+				continue
+			}
+
+			var err error
+			leftover, err = fillPool(br, pool, leftover, opts.Courses)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range br.AppliedCourses {
+				uses[key]++
+				report.Assignments = append(report.Assignments, Assignment{Course: key, Block: br.Name, Option: -1})
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if uses[key] == 0 {
+			report.Unused = append(report.Unused, key)
+		}
+	}
+
+	report.Complete = true
+	for _, br := range report.Blocks {
+		report.NumRequired += br.NumRequired
+		report.NumCompleted += br.NumCompleted
+		if !br.Satisfied {
+			report.Complete = false
+		}
+	}
+
+	return report, nil
+}
+
+// fillPool applies eligible courses to a counted block and returns the ones it did not take
+func fillPool(br *BlockResult, pool *ElectivePool, courses []string, store *scraper.CoursesStore) ([]string, error) {
+	br.Verifiable = true
+	rest := make([]string, 0, len(courses))
+	for _, key := range courses {
+		if br.NumCompleted >= br.NumRequired {
+			rest = append(rest, key)
+			continue
+		}
+
+		ok, err := pool.Eligible(key, schoolOf(store, key))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			rest = append(rest, key)
+			continue
+		}
+
+		br.AppliedCourses = append(br.AppliedCourses, key)
+		br.NumCompleted++
+	}
+
+	br.NumRemaining = br.NumRequired - br.NumCompleted
+	br.Satisfied = br.NumRemaining == 0
+	return rest, nil
+}
+
+// AuditResolved audits a major merged with the core and marks where each block came from
+func AuditResolved(rr *scraper.ResolvedRequirements, completed []string, opts AuditOptions) (*AuditReport, error) {
+	report, err := Audit(rr.MajorRequirements(), completed, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, block := range rr.AllRequirements {
+		report.Blocks[i].Source = block.Source
+	}
+	return report, nil
+}
+
+func countBlock(name string, kind, numreqs int) BlockResult {
+	return BlockResult{
+		Name:            name,
+		RequirementType: kind,
+		NumRequired:     numreqs,
+		NumRemaining:    numreqs,
+		AppliedCourses:  []string{},
+	}
+}
+
+func AuditHandler(store *scraper.MajorRequirementsStore, courses *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AuditRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Major == "" {
+			http.Error(w, "Major parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		completed, ambiguous := courses.ResolveKeys(req.Completed)
+		if len(ambiguous) > 0 {
+			scraper.WriteAmbiguousKeys(w, ambiguous)
+			return
+		}
+		req.Completed = completed
+
+		// engineering majors are audited together with the core
+		resolved, found := store.GetResolvedRequirementsForYear(req.Major, req.Year)
+		if !found {
+			http.Error(w, "Major not found", http.StatusNotFound)
+			return
+		}
+
+		opts := AuditOptions{MaxUses: req.MaxUses, Courses: courses}
+		if req.Theme != "" {
+			theme, found := GetElectivePool(req.Theme)
+			if !found || theme.Type != scraper.THEME_REQUIREMENTS {
+				http.Error(w, "Theme not found", http.StatusNotFound)
+				return
+			}
+			opts.Theme = theme
+		}
+
+		report, err := AuditResolved(resolved, req.Completed, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error auditing requirements: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
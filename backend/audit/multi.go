@@ -0,0 +1,278 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+// at most Max courses may count towards both programs
+type OverlapLimit struct {
+	Programs []string `json:"programs"`
+	Max      int      `json:"max"`
+}
+
+type MultiAuditRequest struct {
+	Programs  []string `json:"programs"`
+	Completed []string `json:"completed"`
+	Year      int      `json:"year"`
+	MaxUses   int      `json:"maxUses"`
+	Theme     string   `json:"theme"`
+	// default limit for every pair of programs, no limit when missing
+	MaxShared     *int           `json:"maxShared"`
+	OverlapLimits []OverlapLimit `json:"overlapLimits"`
+}
+
+type ProgramAudit struct {
+	Program string       `json:"program"`
+	Report  *AuditReport `json:"report"`
+	// shared courses that were taken out of this program to respect the overlap limits
+	Excluded []string `json:"excluded"`
+}
+
+type SharedCourse struct {
+	Course   string   `json:"course"`
+	Programs []string `json:"programs"`
+}
+
+type MultiAuditReport struct {
+	Complete bool           `json:"complete"`
+	Programs []ProgramAudit `json:"programs"`
+	Shared   []SharedCourse `json:"shared"`
+}
+
+// program pairs are keyed by their indexes, -1 means no limit
+type overlapLimits func(a, b int) int
+
+// courses a program applied with the source of the block they went to
+func appliedSources(report *AuditReport) map[string][]string {
+	applied := make(map[string][]string)
+	for _, block := range report.Blocks {
+		for _, key := range block.AppliedCourses {
+			applied[key] = append(applied[key], block.Source)
+		}
+	}
+	return applied
+}
+
+// a course is shared by two programs when both apply it, unless both applied it
+// to blocks of the same origin like the core engineering requirements
+func sharedBetween(a, b map[string][]string) []string {
+	var shared []string
+	for key, sourcesA := range a {
+		sourcesB, ok := b[key]
+		if !ok {
+			continue
+		}
+
+		common := false
+		for _, src := range sourcesA {
+			if src != "" && slices.Contains(sourcesB, src) {
+				common = true
+				break
+			}
+		}
+		if !common {
+			shared = append(shared, key)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+func without(courses []string, excluded []string) []string {
+	rest := make([]string, 0, len(courses))
+	for _, key := range courses {
+		if !slices.Contains(excluded, NormalizeCourseKey(key)) {
+			rest = append(rest, key)
+		}
+	}
+	return rest
+}
+
+// AuditPrograms audits every program against the same courses, while a pair of
+// programs shares more courses than allowed the shared course whose removal costs
+// the fewest completed requirements is taken out of one of the two programs
+func AuditPrograms(programs []*scraper.ResolvedRequirements, completed []string, opts AuditOptions, limit overlapLimits) (*MultiAuditReport, error) {
+	excluded := make([][]string, len(programs))
+	reports := make([]*AuditReport, len(programs))
+
+	run := func(i int, extra string) (*AuditReport, error) {
+		skip := excluded[i]
+		if extra != "" {
+			skip = append(slices.Clone(skip), extra)
+		}
+		return AuditResolved(programs[i], without(completed, skip), opts)
+	}
+
+	for i := range programs {
+		report, err := run(i, "")
+		if err != nil {
+			return nil, err
+		}
+		reports[i] = report
+	}
+
+	for {
+		a, b, shared := -1, -1, []string(nil)
+		for i := range programs {
+			for j := i + 1; j < len(programs); j++ {
+				allowed := limit(i, j)
+				if allowed < 0 {
+					continue
+				}
+				s := sharedBetween(appliedSources(reports[i]), appliedSources(reports[j]))
+				if len(s) > allowed {
+					a, b, shared = i, j, s
+					break
+				}
+			}
+			if a != -1 {
+				break
+			}
+		}
+		if a == -1 {
+			break
+		}
+
+		bestLoss, bestProgram, bestCourse := -1, -1, ""
+		var bestReport *AuditReport
+		for _, key := range shared {
+			for _, p := range []int{a, b} {
+				report, err := run(p, key)
+				if err != nil {
+					return nil, err
+				}
+				loss := reports[p].NumCompleted - report.NumCompleted
+				if bestLoss == -1 || loss < bestLoss {
+					bestLoss, bestProgram, bestCourse, bestReport = loss, p, key, report
+				}
+			}
+		}
+
+		excluded[bestProgram] = append(excluded[bestProgram], bestCourse)
+		reports[bestProgram] = bestReport
+	}
+
+	res := &MultiAuditReport{
+		Complete: true,
+		Programs: make([]ProgramAudit, 0, len(programs)),
+		Shared:   []SharedCourse{},
+	}
+
+	sharedBy := make(map[string][]string)
+	for i := range programs {
+		for j := i + 1; j < len(programs); j++ {
+			for _, key := range sharedBetween(appliedSources(reports[i]), appliedSources(reports[j])) {
+				for _, name := range []string{programs[i].Major, programs[j].Major} {
+					if !slices.Contains(sharedBy[key], name) {
+						sharedBy[key] = append(sharedBy[key], name)
+					}
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(sharedBy))
+	for key := range sharedBy {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		res.Shared = append(res.Shared, SharedCourse{Course: key, Programs: sharedBy[key]})
+	}
+
+	for i, rr := range programs {
+		ex := excluded[i]
+		if ex == nil {
+			ex = []string{}
+		}
+		res.Programs = append(res.Programs, ProgramAudit{Program: rr.Major, Report: reports[i], Excluded: ex})
+		if !reports[i].Complete {
+			res.Complete = false
+		}
+	}
+
+	return res, nil
+}
+
+func MultiAuditHandler(store *scraper.MajorRequirementsStore, courses *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MultiAuditRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Programs) < 2 {
+			http.Error(w, "At least two programs are required", http.StatusBadRequest)
+			return
+		}
+
+		completed, ambiguous := courses.ResolveKeys(req.Completed)
+		if len(ambiguous) > 0 {
+			scraper.WriteAmbiguousKeys(w, ambiguous)
+			return
+		}
+		req.Completed = completed
+
+		programs := make([]*scraper.ResolvedRequirements, 0, len(req.Programs))
+		for _, name := range req.Programs {
+			resolved, found := store.GetResolvedRequirementsForYear(name, req.Year)
+			if !found {
+				http.Error(w, fmt.Sprintf("Major %s not found", name), http.StatusNotFound)
+				return
+			}
+			programs = append(programs, resolved)
+		}
+
+		pairLimits := make(map[[2]int]int)
+		for _, ol := range req.OverlapLimits {
+			if len(ol.Programs) != 2 || ol.Max < 0 {
+				http.Error(w, "Overlap limits need two programs and a non negative max", http.StatusBadRequest)
+				return
+			}
+			a := slices.IndexFunc(req.Programs, func(p string) bool { return strings.EqualFold(p, ol.Programs[0]) })
+			b := slices.IndexFunc(req.Programs, func(p string) bool { return strings.EqualFold(p, ol.Programs[1]) })
+			if a == -1 || b == -1 || a == b {
+				http.Error(w, "Overlap limits must name two of the requested programs", http.StatusBadRequest)
+				return
+			}
+			pairLimits[[2]int{min(a, b), max(a, b)}] = ol.Max
+		}
+
+		limit := func(a, b int) int {
+			if allowed, ok := pairLimits[[2]int{a, b}]; ok {
+				return allowed
+			}
+			if req.MaxShared != nil {
+				return *req.MaxShared
+			}
+			return -1
+		}
+
+		opts := AuditOptions{MaxUses: req.MaxUses, Courses: courses}
+		if req.Theme != "" {
+			theme, found := GetElectivePool(req.Theme)
+			if !found || theme.Type != scraper.THEME_REQUIREMENTS {
+				http.Error(w, "Theme not found", http.StatusNotFound)
+				return
+			}
+			opts.Theme = theme
+		}
+
+		report, err := AuditPrograms(programs, req.Completed, opts, limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error auditing programs: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
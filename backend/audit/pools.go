@@ -0,0 +1,310 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/reqlang"
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+// ElectivePool decides which courses count towards a theme or unrestricted block.
+// empty Schools or Subjects allow everything, a zero MaxLevel has no upper bound and
+// Exclude holds course keys or selectors in the requirement language like "* 398..399"
+type ElectivePool struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     int      `json:"type"`
+	Schools  []string `json:"schools"`
+	Subjects []string `json:"subjects"`
+	MinLevel int      `json:"minLevel"`
+	MaxLevel int      `json:"maxLevel"`
+	Exclude  []string `json:"exclude"`
+
+	excluded []reqlang.Expr
+}
+
+// tutorials and independent study never count towards electives
+var poolExclusions = []string{"* 398..399"}
+
+var natSciExclusions = []string{
+	"MATH *", "STAT *", "PHYSICS *", "CHEM *", "BIOL_SCI *", "ASTRON *", "EARTH *",
+	"NEUROBIO *", "INTG_SCI *", "ENVR_SCI *", "ISEN *",
+}
+
+// McCormick lets students pick a social sciences/humanities theme, these are
+// approximations of the catalog themes by subject
+var ENGINEERING_THEMES = []ElectivePool{
+	{
+		ID:       "ssh",
+		Name:     "Social Sciences/Humanities",
+		Type:     scraper.THEME_REQUIREMENTS,
+		Schools:  []string{"WCAS"},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  append(append([]string{}, poolExclusions...), natSciExclusions...),
+	},
+	{
+		ID:       "economics-and-business",
+		Name:     "Economics and Business",
+		Type:     scraper.THEME_REQUIREMENTS,
+		Subjects: []string{"ECON", "BUS_INST", "MMSS"},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+	{
+		ID:       "ethics-and-values",
+		Name:     "Ethics and Values",
+		Type:     scraper.THEME_REQUIREMENTS,
+		Subjects: []string{"PHIL", "RELIGION", "LEGAL_ST"},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+	{
+		ID:       "fine-arts",
+		Name:     "Fine Arts",
+		Type:     scraper.THEME_REQUIREMENTS,
+		Subjects: []string{"ART", "ART_HIST"},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+	{
+		ID:       "history-and-society",
+		Name:     "History and Society",
+		Type:     scraper.THEME_REQUIREMENTS,
+		Subjects: []string{"HISTORY", "SOCIOL", "ANTHRO", "POLI_SCI", "INTL_ST", "ENVR_POL", "GBL_HLTH"},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+	{
+		ID:   "literature-and-languages",
+		Name: "Literature and Languages",
+		Type: scraper.THEME_REQUIREMENTS,
+		Subjects: []string{
+			"ENGLISH", "COMP_LIT", "CLASSICS", "GREEK", "LATIN", "FRENCH", "GERMAN", "ITALIAN",
+			"SPANISH", "PORT", "SPANPORT", "RUSSIAN", "POLISH", "SLAVIC", "CHINESE", "JAPANESE",
+			"KOREAN", "ARABIC", "HEBREW", "HIND_URD", "SWAHILI", "TURKISH", "ASIAN_LC", "TRANS",
+		},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+	{
+		ID:   "identity-and-culture",
+		Name: "Identity and Culture",
+		Type: scraper.THEME_REQUIREMENTS,
+		Subjects: []string{
+			"AFST", "AMER_ST", "ASIAN_AM", "BLK_ST", "GNDR_ST", "JWSH_ST", "LATINO", "LATIN_AM",
+			"NAIS", "MENA", "HUM",
+		},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+	{
+		ID:       "mind-and-behavior",
+		Name:     "Mind and Behavior",
+		Type:     scraper.THEME_REQUIREMENTS,
+		Subjects: []string{"PSYCH", "COG_SCI", "LING"},
+		MinLevel: 100,
+		MaxLevel: 399,
+		Exclude:  poolExclusions,
+	},
+}
+
+var UNRESTRICTED_POOL = ElectivePool{
+	ID:       "unrestricted",
+	Name:     "Unrestricted Electives",
+	Type:     scraper.UNRESTRICTED_REQUIREMENTS,
+	MinLevel: 100,
+	MaxLevel: 399,
+	Exclude:  poolExclusions,
+}
+
+const DEFAULT_THEME = "ssh"
+
+func GetElectivePools() []ElectivePool {
+	pools := make([]ElectivePool, 0, len(ENGINEERING_THEMES)+1)
+	pools = append(pools, ENGINEERING_THEMES...)
+	return append(pools, UNRESTRICTED_POOL)
+}
+
+// the pools are shared by every request so their exclusions are parsed once up front
+// instead of racing to cache them on first use
+func init() {
+	for i := range ENGINEERING_THEMES {
+		if err := ENGINEERING_THEMES[i].compile(); err != nil {
+			panic(err)
+		}
+	}
+	if err := UNRESTRICTED_POOL.compile(); err != nil {
+		panic(err)
+	}
+}
+
+func GetElectivePool(id string) (*ElectivePool, bool) {
+	for i := range ENGINEERING_THEMES {
+		if strings.EqualFold(ENGINEERING_THEMES[i].ID, id) {
+			return &ENGINEERING_THEMES[i], true
+		}
+	}
+	if strings.EqualFold(UNRESTRICTED_POOL.ID, id) {
+		return &UNRESTRICTED_POOL, true
+	}
+	return nil, false
+}
+
+func (p *ElectivePool) compile() error {
+	if p.excluded != nil || len(p.Exclude) == 0 {
+		return nil
+	}
+
+	excluded := make([]reqlang.Expr, 0, len(p.Exclude))
+	for _, src := range p.Exclude {
+		expr, err := reqlang.Parse(src)
+		if err != nil {
+			return fmt.Errorf("pool %s: invalid exclusion %q: %w", p.ID, src, err)
+		}
+		excluded = append(excluded, expr)
+	}
+	p.excluded = excluded
+	return nil
+}
+
+func (p *ElectivePool) isExcluded(key string) bool {
+	for _, expr := range p.excluded {
+		switch e := expr.(type) {
+		case reqlang.Course:
+			if NormalizeCourseKey(e.Key) == key {
+				return true
+			}
+		case reqlang.Pick:
+			if e.Selector.Matches(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Eligible checks a course key, an empty school means it could not be told from the
+// catalog and only the subjects, levels and exclusions apply
+func (p *ElectivePool) Eligible(key, school string) (bool, error) {
+	if err := p.compile(); err != nil {
+		return false, err
+	}
+
+	key = NormalizeCourseKey(key)
+	subject, number, ok := reqlang.SplitCourseKey(key)
+	if !ok {
+		return false, nil
+	}
+
+	if len(p.Subjects) > 0 && !slices.Contains(p.Subjects, subject) {
+		return false, nil
+	}
+	if len(p.Schools) > 0 && school != "" && !slices.ContainsFunc(p.Schools, func(s string) bool { return strings.EqualFold(s, school) }) {
+		return false, nil
+	}
+	if number < p.MinLevel || (p.MaxLevel > 0 && number > p.MaxLevel) {
+		return false, nil
+	}
+	return !p.isExcluded(key), nil
+}
+
+// school of a course key from any of its offered sections, a course taken in a quarter
+// that is not loaded falls back to the school of another course in its subject
+func schoolOf(store *scraper.CoursesStore, key string) string {
+	if store == nil {
+		return ""
+	}
+	if school := offeredSchool(store, key); school != "" {
+		return school
+	}
+
+	subject, _, ok := reqlang.SplitCourseKey(NormalizeCourseKey(key))
+	if !ok {
+		return ""
+	}
+	for other := range store.CoursesBySubject[subject] {
+		if school := offeredSchool(store, other); school != "" {
+			return school
+		}
+	}
+	return ""
+}
+
+func offeredSchool(store *scraper.CoursesStore, key string) string {
+	for _, course := range store.GetCoursesByKey(key) {
+		if course.School != "" {
+			return course.School
+		}
+	}
+	return ""
+}
+
+func QualifyingCourses(store *scraper.CoursesStore, pool *ElectivePool, quarter int) ([]*scraper.Course, error) {
+	courses := make([]*scraper.Course, 0)
+	for _, course := range store.GetCoursesByQuarter(quarter) {
+		ok, err := pool.Eligible(scraper.GetCourseKey(*course), course.School)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			courses = append(courses, course)
+		}
+	}
+	return courses, nil
+}
+
+func GetElectivePoolsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GetElectivePools())
+	}
+}
+
+func GetPoolCoursesHandler(store *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		poolID := r.URL.Query().Get("pool")
+		if poolID == "" {
+			http.Error(w, "Pool parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		quarterStr := r.URL.Query().Get("quarter")
+		if quarterStr == "" {
+			http.Error(w, "Quarter parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		quarter, err := strconv.Atoi(quarterStr)
+		if err != nil {
+			http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+			return
+		}
+
+		pool, found := GetElectivePool(poolID)
+		if !found {
+			http.Error(w, "Pool not found", http.StatusNotFound)
+			return
+		}
+
+		courses, err := QualifyingCourses(store, pool, quarter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error evaluating pool: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(courses)
+	}
+}
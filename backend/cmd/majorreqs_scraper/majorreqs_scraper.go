@@ -20,6 +20,7 @@ func main() {
 
 	// Parse command line flags
 	major := flag.String("major", "", "Major to retrieve requirements for")
+	year := flag.Int("year", scraper.DEFAULT_CATALOG_YEAR, "Catalog year the requirements are for")
 	flag.Parse()
 
 	if *major == "" {
@@ -46,6 +47,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	mr.CatalogYear = *year
+
 	// Write the major requirements to the database
 	err = db.WriteMajorReqsToDatabase(database, &mr)
 	if err != nil {
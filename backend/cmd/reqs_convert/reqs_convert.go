@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nynniaw12/ieee-planner/reqlang"
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+// prints major requirement json files in the requirement expression language and
+// checks that converting them back gives the same json
+func main() {
+	dir := flag.String("dir", "./scraper-out/majorreqs/", "Directory of major requirement json files")
+	quiet := flag.Bool("quiet", false, "Only report round trip failures")
+	flag.Parse()
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		fmt.Printf("Error listing files: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, file := range files {
+		if err := convert(file, *quiet); err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func convert(file string, quiet bool) error {
+	mr, err := scraper.ReadMajorreqsFromJSON(file)
+	if err != nil {
+		return err
+	}
+
+	prog, err := reqlang.FromMajorRequirements(mr)
+	if err != nil {
+		return err
+	}
+
+	text := prog.String()
+	if !quiet {
+		fmt.Printf("# %s\n%s\n", file, text)
+	}
+
+	parsed, err := reqlang.ParseProgram(text)
+	if err != nil {
+		return fmt.Errorf("error parsing converted program: %w", err)
+	}
+
+	back, err := parsed.ToMajorRequirements()
+	if err != nil {
+		return err
+	}
+
+	want, err := json.Marshal(mr)
+	if err != nil {
+		return err
+	}
+	got, err := json.Marshal(back)
+	if err != nil {
+		return err
+	}
+	if string(want) != string(got) {
+		return fmt.Errorf("round trip changed the requirements")
+	}
+	return nil
+}
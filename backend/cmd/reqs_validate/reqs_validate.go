@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+// checks the major requirement json files against the scraped course catalog and
+// prints the issues as json, exits with 1 when there are errors
+func main() {
+	dir := flag.String("dir", "./scraper-out/majorreqs/", "Directory of major requirement json files")
+	coursesDir := flag.String("courses", "./scraper-out/courses/", "Directory of course json files")
+	strict := flag.Bool("strict", false, "Fail on warnings as well as errors")
+	flag.Parse()
+
+	courses, err := scraper.NewCoursesStore(*coursesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading courses: %v\n", err)
+		os.Exit(2)
+	}
+
+	// catalog years may have a directory of their own
+	files, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing files: %v\n", err)
+		os.Exit(2)
+	}
+	yearFiles, err := filepath.Glob(filepath.Join(*dir, "*", "*.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing files: %v\n", err)
+		os.Exit(2)
+	}
+	files = append(files, yearFiles...)
+
+	report := &scraper.ValidationReport{Issues: []scraper.ValidationIssue{}}
+	for _, file := range files {
+		fileReport := scraper.ValidateMajorRequirementsFile(file, courses)
+		report.Errors += fileReport.Errors
+		report.Warnings += fileReport.Warnings
+		report.Issues = append(report.Issues, fileReport.Issues...)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(2)
+	}
+
+	if report.Errors > 0 || (*strict && report.Warnings > 0) {
+		os.Exit(1)
+	}
+}
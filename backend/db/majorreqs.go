@@ -18,29 +18,37 @@ func WriteMajorReqsToDatabase(db *sql.DB, mr *scraper.MajorRequirements) error {
 		return fmt.Errorf("error converting requirements to JSON: %w", err)
 	}
 
-	// Upsert query to either insert or update existing major
+	// Upsert query to either insert or update existing major for the catalog year
 	query := `
-	INSERT INTO major_requirements (major, is_engineering, requirements)
-	VALUES ($1, $2, $3)
-	ON CONFLICT (major) 
+	INSERT INTO major_requirements (major, catalog_year, is_engineering, requirements)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (major, catalog_year) 
 	DO UPDATE SET 
-		is_engineering = $2,
-		requirements = $3,
+		is_engineering = $3,
+		requirements = $4,
 		updated_at = CURRENT_TIMESTAMP
 	RETURNING id;`
 
 	var id int
-	err = db.QueryRow(query, strings.ToLower(mr.Major), mr.IsEngineering, reqsJSON).Scan(&id)
+	err = db.QueryRow(query, strings.ToLower(mr.Major), catalogYear(mr), mr.IsEngineering, reqsJSON).Scan(&id)
 	if err != nil {
 		return fmt.Errorf("error inserting/updating major requirements: %w", err)
 	}
 
-	log.Printf("Major %s requirements saved with ID %d\n", mr.Major, id)
+	log.Printf("Major %s %d requirements saved with ID %d\n", mr.Major, catalogYear(mr), id)
 	return nil
 }
 
+// requirements without a catalog year are from the catalog the scraper was last run on
+func catalogYear(mr *scraper.MajorRequirements) int {
+	if mr.CatalogYear == 0 {
+		return scraper.DEFAULT_CATALOG_YEAR
+	}
+	return mr.CatalogYear
+}
+
 // WriteBulkMajorReqsToDatabase writes multiple majors' requirements to the database
-func WriteBulkMajorReqsToDatabase(db *sql.DB, majors map[string]*scraper.MajorRequirements) error {
+func WriteBulkMajorReqsToDatabase(db *sql.DB, majors []*scraper.MajorRequirements) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
@@ -49,12 +57,12 @@ func WriteBulkMajorReqsToDatabase(db *sql.DB, majors map[string]*scraper.MajorRe
 
 	// Prepare the statement for better performance with multiple inserts
 	stmt, err := tx.Prepare(`
-		INSERT INTO major_requirements (major, is_engineering, requirements)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (major) 
+		INSERT INTO major_requirements (major, catalog_year, is_engineering, requirements)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (major, catalog_year) 
 		DO UPDATE SET 
-			is_engineering = $2,
-			requirements = $3,
+			is_engineering = $3,
+			requirements = $4,
 			updated_at = CURRENT_TIMESTAMP
 	`)
 	if err != nil {
@@ -68,7 +76,7 @@ func WriteBulkMajorReqsToDatabase(db *sql.DB, majors map[string]*scraper.MajorRe
 			return fmt.Errorf("error converting requirements to JSON for major %s: %w", mr.Major, err)
 		}
 
-		_, err = stmt.Exec(strings.ToLower(mr.Major), mr.IsEngineering, reqsJSON)
+		_, err = stmt.Exec(strings.ToLower(mr.Major), catalogYear(mr), mr.IsEngineering, reqsJSON)
 		if err != nil {
 			return fmt.Errorf("error inserting/updating major %s: %w", mr.Major, err)
 		}
@@ -82,18 +90,22 @@ func WriteBulkMajorReqsToDatabase(db *sql.DB, majors map[string]*scraper.MajorRe
 	return nil
 }
 
-// GetMajorReqsFromDatabase retrieves a single major's requirements from the database
-func GetMajorReqsFromDatabase(db *sql.DB, major string) (*scraper.MajorRequirements, error) {
+// GetMajorReqsFromDatabase retrieves a single major's requirements from the database,
+// a year of 0 gives the most recent catalog year
+func GetMajorReqsFromDatabase(db *sql.DB, major string, year int) (*scraper.MajorRequirements, error) {
 	query := `
-	SELECT major, is_engineering, requirements
+	SELECT major, catalog_year, is_engineering, requirements
 	FROM major_requirements
-	WHERE major = $1;`
+	WHERE major = $1 AND ($2 = 0 OR catalog_year = $2)
+	ORDER BY catalog_year DESC
+	LIMIT 1;`
 
 	var majorName string
+	var catalogYear int
 	var isEngineering bool
 	var reqsJSON []byte
 
-	err := db.QueryRow(query, strings.ToLower(major)).Scan(&majorName, &isEngineering, &reqsJSON)
+	err := db.QueryRow(query, strings.ToLower(major), year).Scan(&majorName, &catalogYear, &isEngineering, &reqsJSON)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("major %s not found", major)
@@ -101,8 +113,7 @@ func GetMajorReqsFromDatabase(db *sql.DB, major string) (*scraper.MajorRequireme
 		return nil, fmt.Errorf("error retrieving major requirements: %w", err)
 	}
 
-	var allReqs []any
-	err = json.Unmarshal(reqsJSON, &allReqs)
+	allReqs, err := scraper.UnmarshalRequirements(reqsJSON)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing requirements JSON: %w", err)
 	}
@@ -111,6 +122,7 @@ func GetMajorReqsFromDatabase(db *sql.DB, major string) (*scraper.MajorRequireme
 	majorReqs := &scraper.MajorRequirements{
 		Major:           majorName,
 		IsEngineering:   isEngineering,
+		CatalogYear:     catalogYear,
 		AllRequirements: allReqs,
 	}
 
@@ -120,7 +132,7 @@ func GetMajorReqsFromDatabase(db *sql.DB, major string) (*scraper.MajorRequireme
 // GetAllMajorsFromDatabase retrieves all majors from the database
 func GetAllMajorsFromDatabase(db *sql.DB) ([]string, error) {
 	query := `
-	SELECT major
+	SELECT DISTINCT major
 	FROM major_requirements
 	ORDER BY major;`
 
@@ -154,6 +166,42 @@ func InitializeMajorReqsFromScraper(db *sql.DB, path string) error {
 		return fmt.Errorf("error creating major requirements store: %w", err)
 	}
 
-	// Write all requirements to database
-	return WriteBulkMajorReqsToDatabase(db, store.RequirementsByMajor)
-}
\ No newline at end of file
+	// Write every catalog year of every major to database
+	majors := make([]*scraper.MajorRequirements, 0)
+	for _, year := range store.Years {
+		for _, mr := range store.RequirementsByYear[year] {
+			majors = append(majors, mr)
+		}
+	}
+	return WriteBulkMajorReqsToDatabase(db, majors)
+}
+
+// GetMajorYearsFromDatabase retrieves the catalog years of a major, newest first
+func GetMajorYearsFromDatabase(db *sql.DB, major string) ([]int, error) {
+	query := `
+	SELECT catalog_year
+	FROM major_requirements
+	WHERE major = $1
+	ORDER BY catalog_year DESC;`
+
+	rows, err := db.Query(query, strings.ToLower(major))
+	if err != nil {
+		return nil, fmt.Errorf("error querying catalog years: %w", err)
+	}
+	defer rows.Close()
+
+	years := make([]int, 0)
+	for rows.Next() {
+		var year int
+		if err := rows.Scan(&year); err != nil {
+			return nil, fmt.Errorf("error scanning catalog year: %w", err)
+		}
+		years = append(years, year)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating catalog years: %w", err)
+	}
+
+	return years, nil
+}
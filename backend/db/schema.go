@@ -4,6 +4,9 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
 )
 
 // CreateMajorReqsTable creates the table for storing major requirements
@@ -11,12 +14,20 @@ func CreateMajorReqsTableIfNotExists(db *sql.DB) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS major_requirements (
 		id SERIAL PRIMARY KEY,
-		major TEXT UNIQUE NOT NULL,
+		major TEXT NOT NULL,
+		catalog_year INTEGER NOT NULL,
 		is_engineering BOOLEAN NOT NULL,
 		requirements JSONB NOT NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
+	);
+
+	-- tables created before catalog years were keyed by major alone
+	ALTER TABLE major_requirements ADD COLUMN IF NOT EXISTS catalog_year INTEGER NOT NULL DEFAULT ` + strconv.Itoa(scraper.DEFAULT_CATALOG_YEAR) + `;
+	ALTER TABLE major_requirements ALTER COLUMN catalog_year DROP DEFAULT;
+	ALTER TABLE major_requirements DROP CONSTRAINT IF EXISTS major_requirements_major_key;
+	-- (major, catalog_year) is unique, also the conflict target of the upserts
+	CREATE UNIQUE INDEX IF NOT EXISTS major_requirements_major_catalog_year_idx ON major_requirements (major, catalog_year);`
 
 	_, err := db.Exec(query)
 	if err != nil {
@@ -13,10 +13,13 @@ import (
 	// "github.com/nynniaw12/ieee-planner/api/handlers" // Not needed for demo mode (using cached files)
 	// "github.com/nynniaw12/ieee-planner/db" // Not needed for demo mode (using cached files)
 
+	"github.com/nynniaw12/ieee-planner/audit"
 	"github.com/nynniaw12/ieee-planner/scraper"
 
 	// _ "github.com/lib/pq"
 	"github.com/nynniaw12/ieee-planner/middleware"
+	"github.com/nynniaw12/ieee-planner/plan"
+	"github.com/nynniaw12/ieee-planner/schedule"
 )
 
 func StartDaemon(timeout time.Duration, f func() error) {
@@ -51,21 +54,44 @@ func main() {
 		log.Fatalf("Error creating majorreqs store: %v", err)
 	}
 
+	// sections by the weekday and time they meet, for free time searches
+	meeting_index := schedule.NewMeetingIndex(courses_store)
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/quarters", scraper.GetAvailableQuartersHandler(courses_store))
 	mux.HandleFunc("GET /api/courses", scraper.GetCoursesByQuarterHandler(courses_store))
 	mux.HandleFunc("GET /api/courses/subject", scraper.GetCoursesBySubjectHandler(courses_store))
 	mux.HandleFunc("GET /api/courses/key", scraper.GetCoursesByKeyHandler(courses_store))
+	mux.HandleFunc("GET /api/courses/resolve", scraper.ResolveKeyHandler(courses_store))
+	mux.HandleFunc("GET /api/courses/prereqs", scraper.GetPrereqsHandler(courses_store))
+	mux.HandleFunc("POST /api/courses/eligible", scraper.EligibilityHandler(courses_store))
+	mux.HandleFunc("GET /api/courses/instructors", scraper.GetCourseInstructorsHandler(courses_store))
+	mux.HandleFunc("GET /api/courses/similar", scraper.GetSimilarCoursesHandler(courses_store))
+	mux.HandleFunc("GET /api/search", scraper.SearchHandler(courses_store))
+	mux.HandleFunc("GET /api/instructors", scraper.GetInstructorsHandler(courses_store))
+	mux.HandleFunc("GET /api/instructors/{id}/schedule", scraper.GetInstructorScheduleHandler(courses_store))
 
 	// Use cached files for majors/reqs (demo mode - no database needed)
 	mux.HandleFunc("GET /api/majors", scraper.GetAvailableMajorsHandler(majorreqs_store))
 	mux.HandleFunc("GET /api/reqs", scraper.GetMajorRequirementsHandler(majorreqs_store))
+	mux.HandleFunc("GET /api/reqs/years", scraper.GetCatalogYearsHandler(majorreqs_store))
+	mux.HandleFunc("GET /api/reqs/diff", scraper.GetRequirementsDiffHandler(majorreqs_store))
+	mux.HandleFunc("POST /api/audit", audit.AuditHandler(majorreqs_store, courses_store))
+	mux.HandleFunc("POST /api/audit/multi", audit.MultiAuditHandler(majorreqs_store, courses_store))
+	mux.HandleFunc("GET /api/pools", audit.GetElectivePoolsHandler())
+	mux.HandleFunc("GET /api/pools/courses", audit.GetPoolCoursesHandler(courses_store))
+	mux.HandleFunc("POST /api/plan/generate", plan.GeneratePlanHandler(majorreqs_store, courses_store))
+	mux.HandleFunc("POST /api/schedule/check", schedule.CheckScheduleHandler(courses_store))
+	mux.HandleFunc("POST /api/schedule/build", schedule.BuildSchedulesHandler(courses_store))
+	mux.HandleFunc("GET /api/schedule/ics", schedule.ScheduleICSHandler(courses_store))
+	mux.HandleFunc("POST /api/schedule/available", schedule.AvailabilityHandler(meeting_index))
 
 	// Database-based handlers (commented out for demo mode)
 	// database := db.ConnectToDB()
 	// defer database.Close()
 	// mux.HandleFunc("GET /api/majors", handlers.GetAvailableMajorsHandler(database))
 	// mux.HandleFunc("GET /api/reqs", handlers.GetMajorRequirementsHandler(database))
+	// mux.HandleFunc("GET /api/reqs/years", handlers.GetCatalogYearsHandler(database))
 
 	// // testing handlers
 	// // http.HandleFunc("GET /api/courses", handlers.CoursesHandler) // request to /courses, call CoursesHandler
@@ -0,0 +1,148 @@
+package page
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// a limit above MAX_LIMIT is lowered to it, without a limit every item is returned
+const MAX_LIMIT = 1000
+
+// Params is where a page starts and how items are ordered, Sort is one of the names an
+// endpoint allows and Desc is set when it was written with a leading "-"
+type Params struct {
+	Limit  int
+	Offset int
+	Sort   string
+	Desc   bool
+}
+
+// Page is the envelope of every list endpoint, NextCursor is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Sort       string `json:"sort,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func (p Params) sortSpec() string {
+	if p.Sort != "" && p.Desc {
+		return "-" + p.Sort
+	}
+	return p.Sort
+}
+
+// cursors carry the offset and the order they were made for so a cursor from one
+// ordering cannot page through another
+func encodeCursor(offset int, sort string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + "|" + sort))
+}
+
+func decodeCursor(cursor string) (int, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+	offsetStr, sort, found := strings.Cut(string(raw), "|")
+	offset, err := strconv.Atoi(offsetStr)
+	if !found || err != nil || offset < 0 {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+	return offset, sort, nil
+}
+
+// ParseParams reads limit, offset or cursor and sort, sorts lists the names the
+// endpoint can order by and defaultSort is used when none is given
+func ParseParams(query url.Values, sorts []string, defaultSort string) (Params, error) {
+	p := Params{}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return p, fmt.Errorf("invalid limit %q", limitStr)
+		}
+		p.Limit = min(limit, MAX_LIMIT)
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = defaultSort
+	}
+	p.Sort = strings.TrimPrefix(sort, "-")
+	p.Desc = strings.HasPrefix(sort, "-")
+	if p.Sort != "" && !slices.Contains(sorts, p.Sort) {
+		return p, fmt.Errorf("invalid sort %q, expected one of %s", p.Sort, strings.Join(sorts, ", "))
+	}
+
+	offsetStr, cursor := query.Get("offset"), query.Get("cursor")
+	switch {
+	case offsetStr != "" && cursor != "":
+		return p, fmt.Errorf("offset and cursor cannot both be set")
+	case offsetStr != "":
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return p, fmt.Errorf("invalid offset %q", offsetStr)
+		}
+		p.Offset = offset
+	case cursor != "":
+		offset, sort, err := decodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		if sort != p.sortSpec() {
+			return p, fmt.Errorf("cursor was made for sort %q", sort)
+		}
+		p.Offset = offset
+	}
+	return p, nil
+}
+
+// Names lists the sort names an endpoint allows, for ParseParams
+func Names[T any](less map[string]T) []string {
+	names := make([]string, 0, len(less))
+	for name := range less {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Sort orders items stably by the requested field, less holds a comparison for every
+// sort name the endpoint allows
+func Sort[T any](items []T, p Params, less map[string]func(a, b T) int) {
+	cmp, found := less[p.Sort]
+	if !found {
+		return
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		if p.Desc {
+			return cmp(b, a)
+		}
+		return cmp(a, b)
+	})
+}
+
+// Slice cuts the page out of items that are already sorted
+func Slice[T any](items []T, p Params) Page[T] {
+	total := len(items)
+	start := min(p.Offset, total)
+	end := total
+	if p.Limit > 0 {
+		end = min(start+p.Limit, total)
+	}
+
+	pg := Page[T]{Items: slices.Clone(items[start:end]), Total: total, Limit: p.Limit, Offset: start, Sort: p.sortSpec()}
+	if pg.Items == nil {
+		pg.Items = []T{}
+	}
+	if end < total {
+		pg.NextCursor = encodeCursor(end, p.sortSpec())
+	}
+	return pg
+}
@@ -0,0 +1,599 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/audit"
+	"github.com/nynniaw12/ieee-planner/scraper"
+	"github.com/nynniaw12/ieee-planner/term"
+)
+
+// regular quarters planned when the request does not say, four years without summers
+const DEFAULT_HORIZON = 12
+
+// the most a request can ask for, six years without summers and a full overload
+const (
+	MAX_HORIZON     = 24
+	MAX_PER_QUARTER = 8
+)
+
+// a bundle that cannot be used is only picked when every other bundle is worse
+const UNUSABLE_COST = 1000
+
+type PlanRequest struct {
+	Major     string   `json:"major"`
+	Completed []string `json:"completed"`
+	Year      int      `json:"year"`
+	MaxUses   int      `json:"maxUses"`
+	Theme     string   `json:"theme"`
+	// first quarter to plan and the most courses taken in any quarter
+	StartQuarter  int `json:"startQuarter"`
+	MaxPerQuarter int `json:"maxPerQuarter"`
+	// regular quarters to plan at most, summers are skipped unless Summer is set
+	Quarters int  `json:"quarters"`
+	Summer   bool `json:"summer"`
+	// treat courses that are not in any loaded quarter as offered every regular quarter
+	AssumeOffered bool `json:"assumeOffered"`
+}
+
+// Course is empty for elective slots, any course of the block's pool fills them
+type PlannedCourse struct {
+	Course   string `json:"course,omitempty"`
+	Block    string `json:"block"`
+	Reason   string `json:"reason,omitempty"`
+	Elective bool   `json:"elective,omitempty"`
+	// the prerequisites can only be met with the permission of the instructor
+	NeedsConsent bool `json:"needsConsent,omitempty"`
+}
+
+type PlannedQuarter struct {
+	Quarter int             `json:"quarter"`
+	Season  string          `json:"season"`
+	Courses []PlannedCourse `json:"courses"`
+}
+
+type UnplannedCourse struct {
+	Course string `json:"course,omitempty"`
+	Block  string `json:"block"`
+	Reason string `json:"reason"`
+}
+
+type Plan struct {
+	Major       string            `json:"major"`
+	Feasible    bool              `json:"feasible"`
+	Quarters    []PlannedQuarter  `json:"quarters"`
+	Unplanned   []UnplannedCourse `json:"unplanned"`
+	Explanation []string          `json:"explanation"`
+}
+
+// a course the plan has to fit in, block is the requirement it fills or for
+// prerequisites the requirement of the course that needs it
+type target struct {
+	key          string
+	block        string
+	reason       string
+	seasons      []term.Season
+	prereqs      *scraper.PrereqExpr
+	coreqs       *scraper.PrereqExpr
+	needsConsent bool
+	quarter      int
+	height       int
+}
+
+type elective struct {
+	block   string
+	quarter int
+}
+
+type planner struct {
+	req       PlanRequest
+	courses   *scraper.CoursesStore
+	done      map[string]bool
+	uses      map[string]int
+	maxUses   int
+	targets   map[string]*target
+	order     []string
+	electives []*elective
+	notes     []string
+}
+
+// seasons the course was offered in across the loaded quarters
+func (p *planner) offeredSeasons(key string) []term.Season {
+	subject, _, _ := strings.Cut(key, " ")
+	seasons := make([]term.Season, 0)
+	if cbs, found := p.courses.CoursesBySubject[subject][key]; found {
+		for _, quarter := range cbs.Quarters {
+			if s := term.Of(quarter).Season; !slices.Contains(seasons, s) {
+				seasons = append(seasons, s)
+			}
+		}
+	}
+	if len(seasons) == 0 && p.req.AssumeOffered {
+		seasons = append(seasons, term.FALL, term.WINTER, term.SPRING)
+	}
+	slices.Sort(seasons)
+	return seasons
+}
+
+func (p *planner) requisites(key string) *scraper.Requisites {
+	if p.courses.PrereqGraph == nil {
+		return nil
+	}
+	return p.courses.PrereqGraph.Requisites[key]
+}
+
+func (p *planner) add(key, block, reason string) {
+	if p.done[key] || p.targets[key] != nil {
+		return
+	}
+	t := &target{key: key, block: block, reason: reason, seasons: p.offeredSeasons(key)}
+	if reqs := p.requisites(key); reqs != nil {
+		t.prereqs = reqs.Prerequisites
+		t.coreqs = reqs.Corequisites
+	}
+	p.targets[key] = t
+	p.order = append(p.order, key)
+}
+
+// known means completed or already part of the plan
+func (p *planner) known(leaf *scraper.PrereqExpr) bool {
+	return leaf.Type != scraper.PREREQ_COURSE || p.done[leaf.Course] || p.targets[leaf.Course] != nil
+}
+
+// the cost of planning a bundle is the courses it adds including their missing
+// prerequisites, courses never offered make a bundle nearly as bad as an unusable one
+func (p *planner) bundleCost(bundle []string) int {
+	cost := 0
+	for _, key := range bundle {
+		switch {
+		case p.done[key]:
+			if p.uses[key] >= p.maxUses {
+				cost += UNUSABLE_COST
+			}
+		case p.targets[key] != nil:
+			if p.maxUses <= 1 {
+				cost += UNUSABLE_COST
+			}
+		default:
+			cost++
+			if len(p.offeredSeasons(key)) == 0 {
+				cost += UNUSABLE_COST / 10
+			}
+			if reqs := p.requisites(key); reqs != nil {
+				cost += len(reqs.Prerequisites.Missing(p.known))
+			}
+		}
+	}
+	return cost
+}
+
+// chooseBundles picks a bundle for every open option and reserves elective slots
+// for the counted blocks
+func (p *planner) chooseBundles(rr *scraper.ResolvedRequirements, report *audit.AuditReport) {
+	for i, block := range rr.AllRequirements {
+		br := report.Blocks[i]
+		if br.Satisfied {
+			continue
+		}
+
+		gr, ok := block.Req.(scraper.GenericRequirements)
+		if !ok {
+			if br.RequirementType == scraper.UNKNOWN_REQUIREMENTS {
+				p.notes = append(p.notes, fmt.Sprintf("an unknown block from %s is planned as %d electives and cannot be checked", block.Source, br.NumRemaining))
+			}
+			for range br.NumRemaining {
+				p.electives = append(p.electives, &elective{block: br.Name})
+			}
+			continue
+		}
+
+		for j, opt := range gr.Requirements {
+			if br.Options[j].Satisfied {
+				continue
+			}
+
+			var best []string
+			bestCost := -1
+			for _, req := range opt.Between {
+				bundle := make([]string, 0, len(req.Courses))
+				for _, key := range req.Courses {
+					bundle = append(bundle, audit.NormalizeCourseKey(key))
+				}
+				if len(bundle) == 0 {
+					continue
+				}
+				if cost := p.bundleCost(bundle); bestCost == -1 || cost < bestCost {
+					best, bestCost = bundle, cost
+				}
+			}
+
+			if bestCost >= UNUSABLE_COST {
+				p.notes = append(p.notes, fmt.Sprintf("option %d of %s can only be met with courses already counted elsewhere", j, gr.Name))
+			}
+			for _, key := range best {
+				if p.done[key] {
+					p.uses[key]++
+				}
+				p.add(key, gr.Name, "")
+			}
+		}
+	}
+}
+
+// expand adds the missing prerequisites and corequisites of every planned course,
+// an or picks the alternative that needs the fewest new courses
+func (p *planner) expand() {
+	for i := 0; i < len(p.order); i++ {
+		t := p.targets[p.order[i]]
+
+		noConsent := func(leaf *scraper.PrereqExpr) bool {
+			return leaf.Type != scraper.PREREQ_CONSENT && p.known(leaf)
+		}
+		for _, leaf := range t.prereqs.Missing(noConsent) {
+			if leaf.Type == scraper.PREREQ_COURSE {
+				p.add(leaf.Course, t.block, "prerequisite for "+t.key)
+			}
+		}
+		for _, leaf := range t.coreqs.Missing(p.known) {
+			if leaf.Type == scraper.PREREQ_COURSE {
+				p.add(leaf.Course, t.block, "corequisite of "+t.key)
+			}
+		}
+	}
+
+	for _, t := range p.targets {
+		t.needsConsent = !t.prereqs.Satisfied(func(leaf *scraper.PrereqExpr) bool {
+			return leaf.Type != scraper.PREREQ_CONSENT && p.known(leaf)
+		})
+	}
+}
+
+// heights are the longest chain of planned courses waiting on a course, those
+// are scheduled first
+func (p *planner) computeHeights() {
+	dependents := make(map[string][]string)
+	for _, key := range p.order {
+		t := p.targets[key]
+		for _, expr := range []*scraper.PrereqExpr{t.prereqs, t.coreqs} {
+			for _, course := range expr.Courses() {
+				if p.targets[course] != nil {
+					dependents[course] = append(dependents[course], key)
+				}
+			}
+		}
+	}
+
+	visiting := make(map[string]bool)
+	var height func(key string) int
+	height = func(key string) int {
+		t := p.targets[key]
+		if t.height > 0 || visiting[key] {
+			return t.height
+		}
+		visiting[key] = true
+		h := 1
+		for _, next := range dependents[key] {
+			h = max(h, height(next)+1)
+		}
+		visiting[key] = false
+		t.height = h
+		return h
+	}
+	for _, key := range p.order {
+		height(key)
+	}
+}
+
+// taken before the quarter, or in it when the prerequisite may be taken concurrently
+func (p *planner) satisfied(t *target, quarter int) bool {
+	return t.prereqs.Satisfied(func(leaf *scraper.PrereqExpr) bool {
+		switch leaf.Type {
+		case scraper.PREREQ_COURSE:
+			if p.done[leaf.Course] {
+				return true
+			}
+			other := p.targets[leaf.Course]
+			return other != nil && other.quarter > 0 && (other.quarter < quarter || leaf.Concurrent && other.quarter == quarter)
+		case scraper.PREREQ_CONSENT:
+			return t.needsConsent
+		}
+		return true
+	})
+}
+
+func (p *planner) coreqsMet(t *target, quarter int) bool {
+	return t.coreqs.Satisfied(func(leaf *scraper.PrereqExpr) bool {
+		if leaf.Type != scraper.PREREQ_COURSE || p.done[leaf.Course] {
+			return true
+		}
+		other := p.targets[leaf.Course]
+		return other != nil && other.quarter > 0 && other.quarter <= quarter
+	})
+}
+
+func (p *planner) ready(t *target, quarter int) bool {
+	return t.quarter == 0 && slices.Contains(t.seasons, term.Of(quarter).Season) && p.satisfied(t, quarter)
+}
+
+// fill places as many ready courses as fit in the quarter, a course with corequisites
+// is placed together with them or not at all
+func (p *planner) fill(quarter int, pending []*target) int {
+	placed := 0
+	for progress := true; progress && placed < p.req.MaxPerQuarter; {
+		progress = false
+		for _, t := range pending {
+			if placed >= p.req.MaxPerQuarter || !p.ready(t, quarter) {
+				continue
+			}
+
+			group := []*target{t}
+			t.quarter = quarter
+			if !p.coreqsMet(t, quarter) {
+				for _, key := range t.coreqs.Courses() {
+					other := p.targets[key]
+					if other != nil && placed+len(group) < p.req.MaxPerQuarter && p.ready(other, quarter) {
+						other.quarter = quarter
+						group = append(group, other)
+					}
+				}
+			}
+
+			if placed+len(group) > p.req.MaxPerQuarter || !p.coreqsMet(t, quarter) {
+				for _, g := range group {
+					g.quarter = 0
+				}
+				continue
+			}
+			placed += len(group)
+			progress = true
+		}
+	}
+	return placed
+}
+
+func (p *planner) horizon() int {
+	if p.req.Quarters <= 0 {
+		return DEFAULT_HORIZON
+	}
+	return min(p.req.Quarters, MAX_HORIZON)
+}
+
+func (p *planner) schedule() []PlannedQuarter {
+	pending := make([]*target, 0, len(p.order))
+	for _, key := range p.order {
+		pending = append(pending, p.targets[key])
+	}
+	// longest chains first, then the courses offered in the fewest seasons
+	sort.SliceStable(pending, func(i, j int) bool {
+		if pending[i].height != pending[j].height {
+			return pending[i].height > pending[j].height
+		}
+		return len(pending[i].seasons) < len(pending[j].seasons)
+	})
+
+	quarters := make([]PlannedQuarter, 0)
+	nextElective := 0
+	for current, planned := term.Of(p.req.StartQuarter), 0; planned < p.horizon(); current = current.Next() {
+		quarter := current.Code
+		if current.Season == term.SUMMER && !p.req.Summer {
+			continue
+		}
+		planned++
+
+		placed := p.fill(quarter, pending)
+		pending = slices.DeleteFunc(pending, func(t *target) bool { return t.quarter != 0 })
+		for ; placed < p.req.MaxPerQuarter && nextElective < len(p.electives); placed++ {
+			p.electives[nextElective].quarter = quarter
+			nextElective++
+		}
+
+		quarters = append(quarters, PlannedQuarter{Quarter: quarter, Season: current.Season.String(), Courses: []PlannedCourse{}})
+		if len(pending) == 0 && nextElective == len(p.electives) {
+			break
+		}
+	}
+
+	index := make(map[int]int, len(quarters))
+	for i, pq := range quarters {
+		index[pq.Quarter] = i
+	}
+	for _, key := range p.order {
+		t := p.targets[key]
+		if t.quarter == 0 {
+			continue
+		}
+		pq := &quarters[index[t.quarter]]
+		pq.Courses = append(pq.Courses, PlannedCourse{Course: t.key, Block: t.block, Reason: t.reason, NeedsConsent: t.needsConsent})
+	}
+	for _, e := range p.electives {
+		if e.quarter != 0 {
+			pq := &quarters[index[e.quarter]]
+			pq.Courses = append(pq.Courses, PlannedCourse{Block: e.block, Elective: true})
+		}
+	}
+
+	// quarters after the last placed course only waited on courses that never fit
+	for len(quarters) > 0 && len(quarters[len(quarters)-1].Courses) == 0 {
+		quarters = quarters[:len(quarters)-1]
+	}
+	return quarters
+}
+
+// why a course did not make it into the plan, checked from the most to the least
+// fundamental reason
+func (p *planner) unplannedReason(t *target) string {
+	if len(t.seasons) == 0 {
+		return fmt.Sprintf("%s is not offered in any loaded quarter", t.key)
+	}
+
+	waiting := make([]string, 0)
+	for _, key := range t.prereqs.Courses() {
+		if other := p.targets[key]; other != nil && other.quarter == 0 {
+			waiting = append(waiting, key)
+		}
+	}
+	for _, key := range waiting {
+		if slices.Contains(p.targets[key].prereqs.Courses(), t.key) {
+			return fmt.Sprintf("%s and %s require each other", t.key, key)
+		}
+	}
+	if len(waiting) > 0 {
+		return fmt.Sprintf("%s waits on %s which could not be planned", t.key, strings.Join(waiting, ", "))
+	}
+
+	seasons := make([]string, 0, len(t.seasons))
+	for _, s := range t.seasons {
+		seasons = append(seasons, s.String())
+	}
+	return fmt.Sprintf("%s (offered in %s) did not fit before the last planned quarter", t.key, strings.Join(seasons, ", "))
+}
+
+// Generate lays the remaining requirements of a major out over the quarters from the
+// start quarter on, the plan is checked by auditing the completed and planned courses
+func Generate(rr *scraper.ResolvedRequirements, req PlanRequest, courses *scraper.CoursesStore, opts audit.AuditOptions) (*Plan, error) {
+	report, err := audit.AuditResolved(rr, req.Completed, opts)
+	if err != nil {
+		return nil, err
+	}
+	req.MaxPerQuarter = min(req.MaxPerQuarter, MAX_PER_QUARTER)
+
+	p := &planner{
+		req:     req,
+		courses: courses,
+		done:    make(map[string]bool),
+		uses:    make(map[string]int),
+		maxUses: max(opts.MaxUses, 1),
+		targets: make(map[string]*target),
+	}
+	for _, key := range req.Completed {
+		p.done[audit.NormalizeCourseKey(key)] = true
+	}
+	for _, a := range report.Assignments {
+		p.uses[a.Course]++
+	}
+
+	p.chooseBundles(rr, report)
+	p.expand()
+	p.computeHeights()
+
+	plan := &Plan{
+		Major:       rr.Major,
+		Quarters:    p.schedule(),
+		Unplanned:   []UnplannedCourse{},
+		Explanation: p.notes,
+	}
+	if plan.Explanation == nil {
+		plan.Explanation = []string{}
+	}
+
+	for _, key := range p.order {
+		if t := p.targets[key]; t.quarter == 0 {
+			plan.Unplanned = append(plan.Unplanned, UnplannedCourse{Course: t.key, Block: t.block, Reason: p.unplannedReason(t)})
+		}
+	}
+	electives := 0
+	for _, e := range p.electives {
+		if e.quarter == 0 {
+			electives++
+			plan.Unplanned = append(plan.Unplanned, UnplannedCourse{Block: e.block, Reason: "no room left for the elective"})
+		}
+	}
+
+	remaining := len(p.order) + len(p.electives)
+	if slots := p.horizon() * req.MaxPerQuarter; remaining > slots {
+		plan.Explanation = append(plan.Explanation, fmt.Sprintf("%d courses remain but %d quarters of %d courses only fit %d", remaining, p.horizon(), req.MaxPerQuarter, slots))
+	} else if electives > 0 {
+		plan.Explanation = append(plan.Explanation, fmt.Sprintf("%d electives did not fit next to the required courses", electives))
+	}
+
+	planned := slices.Clone(req.Completed)
+	for _, key := range p.order {
+		if p.targets[key].quarter != 0 {
+			planned = append(planned, key)
+		}
+	}
+	final, err := audit.AuditResolved(rr, planned, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan.Feasible = len(plan.Unplanned) == 0
+	for _, br := range final.Blocks {
+		if br.RequirementType == scraper.GENERIC_REQUIREMENTS && !br.Satisfied {
+			plan.Feasible = false
+			plan.Explanation = append(plan.Explanation, fmt.Sprintf("%s is still %d short after the plan", br.Name, br.NumRemaining))
+		}
+	}
+	return plan, nil
+}
+
+func GeneratePlanHandler(store *scraper.MajorRequirementsStore, courses *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Major == "" {
+			http.Error(w, "Major parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		if req.StartQuarter <= 0 {
+			http.Error(w, "Start quarter is required", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := term.FromCode(req.StartQuarter); err != nil {
+			http.Error(w, "Invalid start quarter", http.StatusBadRequest)
+			return
+		}
+
+		if req.MaxPerQuarter <= 0 || req.MaxPerQuarter > MAX_PER_QUARTER {
+			http.Error(w, fmt.Sprintf("Max per quarter must be between 1 and %d", MAX_PER_QUARTER), http.StatusBadRequest)
+			return
+		}
+
+		if req.Quarters > MAX_HORIZON {
+			http.Error(w, fmt.Sprintf("Quarters must be at most %d", MAX_HORIZON), http.StatusBadRequest)
+			return
+		}
+
+		completed, ambiguous := courses.ResolveKeys(req.Completed)
+		if len(ambiguous) > 0 {
+			scraper.WriteAmbiguousKeys(w, ambiguous)
+			return
+		}
+		req.Completed = completed
+
+		resolved, found := store.GetResolvedRequirementsForYear(req.Major, req.Year)
+		if !found {
+			http.Error(w, "Major not found", http.StatusNotFound)
+			return
+		}
+
+		opts := audit.AuditOptions{MaxUses: req.MaxUses, Courses: courses}
+		if req.Theme != "" {
+			theme, found := audit.GetElectivePool(req.Theme)
+			if !found || theme.Type != scraper.THEME_REQUIREMENTS {
+				http.Error(w, "Theme not found", http.StatusNotFound)
+				return
+			}
+			opts.Theme = theme
+		}
+
+		plan, err := Generate(resolved, req, courses, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error generating plan: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(plan)
+	}
+}
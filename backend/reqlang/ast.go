@@ -0,0 +1,154 @@
+package reqlang
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of a requirement expression
+//
+//	MATH 220-1                         a single course
+//	all(A, B) / A and B                every child
+//	any(A, B) / A or B                 at least one child
+//	2 of (A, B, C)                     at least n children
+//	2 of COMP_SCI 3xx                  n courses matching a selector
+//	1.5 units of (A, B) / of selector  a minimum number of units
+type Expr interface {
+	String() string
+}
+
+type Course struct {
+	Key string
+}
+
+type All struct {
+	Children []Expr
+}
+
+type Any struct {
+	Children []Expr
+}
+
+type Choose struct {
+	N        int
+	Children []Expr
+}
+
+// course pattern, Subject "*" matches every subject and a zero MaxLevel means no upper bound
+type Selector struct {
+	Subject  string
+	MinLevel int
+	MaxLevel int
+}
+
+type Pick struct {
+	N        int
+	Selector Selector
+}
+
+// either Children or Selector is used, never both
+type Units struct {
+	Min      float64
+	Children []Expr
+	Selector *Selector
+}
+
+var (
+	courseNumberRegex = regexp.MustCompile(`^[0-9]{3}[A-Z]?(-[0-9A-Z]+)?$`)
+	subjectRegex      = regexp.MustCompile(`^[A-Z][A-Z0-9_&]*$`)
+	levelRegex        = regexp.MustCompile(`^([0-9])(xx|XX)$`)
+	minLevelRegex     = regexp.MustCompile(`^([0-9]+)\+$`)
+	levelRangeRegex   = regexp.MustCompile(`^([0-9]+)\.\.([0-9]+)$`)
+	numberRegex       = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// SplitCourseKey breaks "COMP_SCI 211-0" into its subject and catalog number
+func SplitCourseKey(key string) (string, int, bool) {
+	parts := strings.Fields(key)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+
+	digits := parts[1]
+	if i := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); i != -1 {
+		digits = digits[:i]
+	}
+
+	number, err := strconv.Atoi(digits)
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.ToUpper(parts[0]), number, true
+}
+
+func (s Selector) Matches(key string) bool {
+	subject, number, ok := SplitCourseKey(key)
+	if !ok {
+		return false
+	}
+	if s.Subject != "*" && !strings.EqualFold(s.Subject, subject) {
+		return false
+	}
+	if number < s.MinLevel {
+		return false
+	}
+	return s.MaxLevel == 0 || number <= s.MaxLevel
+}
+
+func (c Course) String() string {
+	parts := strings.Fields(c.Key)
+	if len(parts) == 2 && parts[0]+" "+parts[1] == c.Key &&
+		subjectRegex.MatchString(parts[0]) && courseNumberRegex.MatchString(parts[1]) {
+		return c.Key
+	}
+	// anything that would not parse back as a course is kept verbatim
+	return strconv.Quote(c.Key)
+}
+
+func (a All) String() string {
+	return "all(" + joinExprs(a.Children) + ")"
+}
+
+func (a Any) String() string {
+	return "any(" + joinExprs(a.Children) + ")"
+}
+
+func (c Choose) String() string {
+	return strconv.Itoa(c.N) + " of (" + joinExprs(c.Children) + ")"
+}
+
+func (s Selector) String() string {
+	var level string
+	switch {
+	case s.MinLevel == 0 && s.MaxLevel == 0:
+		level = "*"
+	case s.MaxLevel == 0:
+		level = strconv.Itoa(s.MinLevel) + "+"
+	case s.MinLevel%100 == 0 && s.MaxLevel == s.MinLevel+99:
+		level = strconv.Itoa(s.MinLevel/100) + "xx"
+	default:
+		level = strconv.Itoa(s.MinLevel) + ".." + strconv.Itoa(s.MaxLevel)
+	}
+	return s.Subject + " " + level
+}
+
+func (p Pick) String() string {
+	return strconv.Itoa(p.N) + " of " + p.Selector.String()
+}
+
+func (u Units) String() string {
+	amount := strconv.FormatFloat(u.Min, 'f', -1, 64)
+	if u.Selector != nil {
+		return amount + " units of " + u.Selector.String()
+	}
+	return amount + " units of (" + joinExprs(u.Children) + ")"
+}
+
+func joinExprs(exprs []Expr) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,207 @@
+package reqlang
+
+import (
+	"sort"
+	"strings"
+)
+
+type Result struct {
+	Satisfied bool `json:"satisfied"`
+	// courses applied towards the expression, each course is counted at most once
+	Used []string `json:"used"`
+	// what is left, written in the expression language
+	Missing []string `json:"missing"`
+	Have    float64  `json:"have"`
+	Need    float64  `json:"need"`
+}
+
+type Evaluator struct {
+	// completed course keys, earlier entries are preferred by selectors
+	Completed []string
+	// units for a course, every course is worth one unit when nil
+	Units func(key string) float64
+}
+
+// Evaluate checks expr against the completed courses, choices are made greedily in order
+func (ev Evaluator) Evaluate(expr Expr) Result {
+	done := make(map[string]bool, len(ev.Completed))
+	for _, key := range ev.Completed {
+		done[normalizeKey(key)] = true
+	}
+
+	st := &evalState{ev: ev, done: done}
+	return st.eval(expr, map[string]bool{})
+}
+
+func Evaluate(expr Expr, completed []string) Result {
+	return Evaluator{Completed: completed}.Evaluate(expr)
+}
+
+type evalState struct {
+	ev   Evaluator
+	done map[string]bool
+}
+
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(key), " "))
+}
+
+func (st *evalState) unitsOf(key string) float64 {
+	if st.ev.Units == nil {
+		return 1
+	}
+	return st.ev.Units(key)
+}
+
+func copySet(set map[string]bool) map[string]bool {
+	cp := make(map[string]bool, len(set))
+	for k, v := range set {
+		cp[k] = v
+	}
+	return cp
+}
+
+func commit(used map[string]bool, res Result) {
+	for _, key := range res.Used {
+		used[normalizeKey(key)] = true
+	}
+}
+
+// used holds the courses already applied by an enclosing expression, children
+// only mark courses as used when their result is kept
+func (st *evalState) eval(expr Expr, used map[string]bool) Result {
+	switch e := expr.(type) {
+	case Course:
+		key := normalizeKey(e.Key)
+		if st.done[key] && !used[key] {
+			return Result{Satisfied: true, Used: []string{e.Key}, Missing: []string{}, Have: 1, Need: 1}
+		}
+		return Result{Used: []string{}, Missing: []string{e.String()}, Need: 1}
+
+	case All:
+		res := Result{Satisfied: true, Used: []string{}, Missing: []string{}, Need: float64(len(e.Children))}
+		for _, child := range e.Children {
+			cr := st.eval(child, used)
+			commit(used, cr)
+			res.Used = append(res.Used, cr.Used...)
+			res.Missing = append(res.Missing, cr.Missing...)
+			if cr.Satisfied {
+				res.Have++
+			} else {
+				res.Satisfied = false
+			}
+		}
+		return res
+
+	case Any:
+		return st.choose(1, e.Children, used)
+
+	case Choose:
+		return st.choose(e.N, e.Children, used)
+
+	case Pick:
+		res := Result{Used: []string{}, Missing: []string{}, Need: float64(e.N)}
+		for _, key := range st.ev.Completed {
+			if res.Have >= res.Need {
+				break
+			}
+			nkey := normalizeKey(key)
+			if !used[nkey] && e.Selector.Matches(nkey) {
+				used[nkey] = true
+				res.Used = append(res.Used, key)
+				res.Have++
+			}
+		}
+		res.Satisfied = res.Have >= res.Need
+		if !res.Satisfied {
+			res.Missing = append(res.Missing, Pick{N: e.N - int(res.Have), Selector: e.Selector}.String())
+		}
+		return res
+
+	case Units:
+		return st.evalUnits(e, used)
+	}
+
+	return Result{Used: []string{}, Missing: []string{expr.String()}}
+}
+
+// at least n children, each child is tried against its own copy of the used set
+// so a partially matched child does not take courses away from its siblings
+func (st *evalState) choose(n int, children []Expr, used map[string]bool) Result {
+	res := Result{Used: []string{}, Missing: []string{}, Need: float64(n)}
+
+	var unsatisfied []Result
+	for _, child := range children {
+		if res.Have >= res.Need {
+			break
+		}
+		cr := st.eval(child, copySet(used))
+		if cr.Satisfied {
+			commit(used, cr)
+			res.Used = append(res.Used, cr.Used...)
+			res.Have++
+		} else {
+			unsatisfied = append(unsatisfied, cr)
+		}
+	}
+
+	res.Satisfied = res.Have >= res.Need
+	if !res.Satisfied {
+		// report the children that are closest to completion
+		remaining := n - int(res.Have)
+		sort.SliceStable(unsatisfied, func(i, j int) bool {
+			return progress(unsatisfied[i]) > progress(unsatisfied[j])
+		})
+		for i := 0; i < remaining && i < len(unsatisfied); i++ {
+			res.Missing = append(res.Missing, unsatisfied[i].Missing...)
+		}
+	}
+	return res
+}
+
+func (st *evalState) evalUnits(e Units, used map[string]bool) Result {
+	res := Result{Used: []string{}, Missing: []string{}, Need: e.Min}
+
+	if e.Selector != nil {
+		for _, key := range st.ev.Completed {
+			if res.Have >= res.Need {
+				break
+			}
+			nkey := normalizeKey(key)
+			if !used[nkey] && e.Selector.Matches(nkey) {
+				used[nkey] = true
+				res.Used = append(res.Used, key)
+				res.Have += st.unitsOf(key)
+			}
+		}
+	} else {
+		for _, child := range e.Children {
+			if res.Have >= res.Need {
+				break
+			}
+			cr := st.eval(child, copySet(used))
+			if !cr.Satisfied {
+				continue
+			}
+			commit(used, cr)
+			res.Used = append(res.Used, cr.Used...)
+			for _, key := range cr.Used {
+				res.Have += st.unitsOf(key)
+			}
+		}
+	}
+
+	res.Satisfied = res.Have >= res.Need
+	if !res.Satisfied {
+		left := Units{Min: e.Min - res.Have, Children: e.Children, Selector: e.Selector}
+		res.Missing = append(res.Missing, left.String())
+	}
+	return res
+}
+
+func progress(r Result) float64 {
+	if r.Need == 0 {
+		return 1
+	}
+	return r.Have / r.Need
+}
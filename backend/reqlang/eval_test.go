@@ -0,0 +1,105 @@
+package reqlang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		completed []string
+		units     map[string]float64
+		satisfied bool
+		used      []string
+		missing   []string
+	}{
+		{
+			name:      "course",
+			src:       "MATH 220-1",
+			completed: []string{"math 220-1"},
+			satisfied: true,
+			used:      []string{"MATH 220-1"},
+			missing:   []string{},
+		},
+		{
+			name:      "all reports every missing child",
+			src:       "all(MATH 220-1, MATH 220-2, MATH 228-1)",
+			completed: []string{"MATH 220-1"},
+			used:      []string{"MATH 220-1"},
+			missing:   []string{"MATH 220-2", "MATH 228-1"},
+		},
+		{
+			name:      "or takes the first satisfied child",
+			src:       "COMP_SCI 111-0 or COMP_SCI 150-0",
+			completed: []string{"COMP_SCI 150-0"},
+			satisfied: true,
+			used:      []string{"COMP_SCI 150-0"},
+			missing:   []string{},
+		},
+		{
+			name:      "n of reports the closest children",
+			src:       "2 of (all(PHYSICS 135-2, PHYSICS 136-2), all(CHEM 131-0, CHEM 141-0), BIOL_SCI 201-0)",
+			completed: []string{"PHYSICS 135-2"},
+			used:      []string{},
+			missing:   []string{"PHYSICS 136-2", "CHEM 131-0", "CHEM 141-0"},
+		},
+		{
+			name:      "a course counts once",
+			src:       "COMP_SCI 211-0 and 1 of COMP_SCI 2xx",
+			completed: []string{"COMP_SCI 211-0"},
+			used:      []string{"COMP_SCI 211-0"},
+			missing:   []string{"1 of COMP_SCI 2xx"},
+		},
+		{
+			name:      "selector picks in completion order",
+			src:       "2 of COMP_SCI 300+",
+			completed: []string{"COMP_SCI 214-0", "COMP_SCI 336-0", "COMP_SCI 349-0", "COMP_SCI 396-0"},
+			satisfied: true,
+			used:      []string{"COMP_SCI 336-0", "COMP_SCI 349-0"},
+			missing:   []string{},
+		},
+		{
+			name:      "units of selector",
+			src:       "1.5 units of ELEC_ENG 3xx",
+			completed: []string{"ELEC_ENG 302-0", "ELEC_ENG 399-0"},
+			units:     map[string]float64{"ELEC_ENG 302-0": 1, "ELEC_ENG 399-0": 0.5},
+			satisfied: true,
+			used:      []string{"ELEC_ENG 302-0", "ELEC_ENG 399-0"},
+			missing:   []string{},
+		},
+		{
+			name:      "units left over",
+			src:       "2 units of (MATH 220-1, MATH 220-2)",
+			completed: []string{"MATH 220-2"},
+			used:      []string{"MATH 220-2"},
+			missing:   []string{"1 units of (MATH 220-1, MATH 220-2)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.src, err)
+			}
+
+			ev := Evaluator{Completed: tt.completed}
+			if tt.units != nil {
+				ev.Units = func(key string) float64 { return tt.units[key] }
+			}
+			res := ev.Evaluate(expr)
+
+			if res.Satisfied != tt.satisfied {
+				t.Errorf("satisfied = %v, want %v", res.Satisfied, tt.satisfied)
+			}
+			if !reflect.DeepEqual(res.Used, tt.used) {
+				t.Errorf("used = %q, want %q", res.Used, tt.used)
+			}
+			if !reflect.DeepEqual(res.Missing, tt.missing) {
+				t.Errorf("missing = %q, want %q", res.Missing, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package reqlang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokColon
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case ch == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case ch == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case ch == ':':
+			tokens = append(tokens, token{tokColon, ":", i})
+			i++
+		case ch == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			text, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", start, err)
+			}
+			tokens = append(tokens, token{tokString, text, start})
+		default:
+			start := i
+			for i < len(src) && !strings.ContainsRune(" \t\n\r#(),:\"", rune(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, src[start:i], start})
+		}
+	}
+	tokens = append(tokens, token{tokEOF, "", len(src)})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(t token, keyword string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s", what)
+	}
+	return t, nil
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	t := p.next()
+	if !p.isKeyword(t, keyword) {
+		return p.errorf(t, "expected %q", keyword)
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	found := t.text
+	if t.kind == tokEOF {
+		found = "end of input"
+	}
+	return fmt.Errorf("%s at %d (found %q)", fmt.Sprintf(format, args...), t.pos, found)
+}
+
+// Parse reads a single requirement expression
+func Parse(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected token")
+	}
+	return expr, nil
+}
+
+// expr := term ("or" term)*
+func (p *parser) parseExpr() (Expr, error) {
+	first, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []Expr{first}
+	for p.isKeyword(p.peek(), "or") {
+		p.next()
+		child, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+	return Any{Children: children}, nil
+}
+
+// term := factor ("and" factor)*
+func (p *parser) parseTerm() (Expr, error) {
+	first, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []Expr{first}
+	for p.isKeyword(p.peek(), "and") {
+		p.next()
+		child, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+	return All{Children: children}, nil
+}
+
+func (p *parser) parseFactor() (Expr, error) {
+	t := p.peek()
+
+	switch {
+	case t.kind == tokString:
+		p.next()
+		return Course{Key: t.text}, nil
+
+	case t.kind == tokLParen:
+		p.next()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+
+	case (p.isKeyword(t, "all") || p.isKeyword(t, "any")) && p.peekAt(1).kind == tokLParen:
+		p.next()
+		p.next()
+		children, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if p.isKeyword(t, "all") {
+			return All{Children: children}, nil
+		}
+		return Any{Children: children}, nil
+
+	case t.kind == tokWord && isNumber(t.text) && (p.isKeyword(p.peekAt(1), "of") || p.isKeyword(p.peekAt(1), "units")):
+		return p.parseCount()
+
+	case t.kind == tokWord:
+		return p.parseCourseOrSelector()
+	}
+
+	return nil, p.errorf(t, "expected a requirement")
+}
+
+// "n of (...)", "n of selector", "x units of (...)", "x units of selector"
+func (p *parser) parseCount() (Expr, error) {
+	numTok := p.next()
+
+	if p.isKeyword(p.peek(), "units") {
+		p.next()
+		if err := p.expectKeyword("of"); err != nil {
+			return nil, err
+		}
+		amount, err := strconv.ParseFloat(numTok.text, 64)
+		if err != nil || amount < 0 {
+			return nil, p.errorf(numTok, "invalid unit count")
+		}
+
+		if p.peek().kind == tokLParen {
+			p.next()
+			children, err := p.parseList()
+			if err != nil {
+				return nil, err
+			}
+			return Units{Min: amount, Children: children}, nil
+		}
+
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		return Units{Min: amount, Selector: &sel}, nil
+	}
+
+	if err := p.expectKeyword("of"); err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(numTok.text)
+	if err != nil || n < 0 {
+		return nil, p.errorf(numTok, "invalid course count")
+	}
+
+	if p.peek().kind == tokLParen {
+		p.next()
+		children, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return Choose{N: n, Children: children}, nil
+	}
+
+	sel, err := p.parseSelector()
+	if err != nil {
+		return nil, err
+	}
+	return Pick{N: n, Selector: sel}, nil
+}
+
+// list := ")" | expr ("," expr)* ")", the opening paren is already consumed
+func (p *parser) parseList() ([]Expr, error) {
+	children := []Expr{}
+	if p.peek().kind == tokRParen {
+		p.next()
+		return children, nil
+	}
+
+	for {
+		child, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+
+		t := p.next()
+		if t.kind == tokRParen {
+			return children, nil
+		}
+		if t.kind != tokComma {
+			return nil, p.errorf(t, "expected ',' or ')'")
+		}
+	}
+}
+
+func (p *parser) parseCourseOrSelector() (Expr, error) {
+	subject := p.peek()
+	number := p.peekAt(1)
+	if number.kind == tokWord && courseNumberRegex.MatchString(number.text) && subjectRegex.MatchString(subject.text) {
+		p.pos += 2
+		return Course{Key: subject.text + " " + number.text}, nil
+	}
+
+	// a bare selector means one matching course
+	sel, err := p.parseSelector()
+	if err != nil {
+		return nil, err
+	}
+	return Pick{N: 1, Selector: sel}, nil
+}
+
+func (p *parser) parseSelector() (Selector, error) {
+	subject := p.next()
+	if subject.kind != tokWord || (subject.text != "*" && !subjectRegex.MatchString(subject.text)) {
+		return Selector{}, p.errorf(subject, "expected a subject")
+	}
+
+	level := p.next()
+	if level.kind != tokWord {
+		return Selector{}, p.errorf(level, "expected a course level")
+	}
+
+	sel := Selector{Subject: subject.text}
+	if level.text == "*" {
+		return sel, nil
+	}
+	if m := levelRegex.FindStringSubmatch(level.text); m != nil {
+		digit, _ := strconv.Atoi(m[1])
+		sel.MinLevel = digit * 100
+		sel.MaxLevel = digit*100 + 99
+		return sel, nil
+	}
+	// the regexes only let digits through, Atoi can still fail on levels that overflow
+	if m := minLevelRegex.FindStringSubmatch(level.text); m != nil {
+		var err error
+		if sel.MinLevel, err = strconv.Atoi(m[1]); err != nil {
+			return Selector{}, p.errorf(level, "invalid course level")
+		}
+		return sel, nil
+	}
+	if m := levelRangeRegex.FindStringSubmatch(level.text); m != nil {
+		var minErr, maxErr error
+		sel.MinLevel, minErr = strconv.Atoi(m[1])
+		sel.MaxLevel, maxErr = strconv.Atoi(m[2])
+		if minErr != nil || maxErr != nil {
+			return Selector{}, p.errorf(level, "invalid course level")
+		}
+		if sel.MaxLevel < sel.MinLevel {
+			return Selector{}, p.errorf(level, "empty course level range")
+		}
+		return sel, nil
+	}
+
+	return Selector{}, p.errorf(level, "expected a course number or level like 3xx, 300+ or 300..399")
+}
+
+// plain decimals only, ParseFloat would also take NaN, Inf and 1e9 as unit counts
+func isNumber(s string) bool {
+	return numberRegex.MatchString(s)
+}
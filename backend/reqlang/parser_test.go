@@ -0,0 +1,77 @@
+package reqlang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Expr
+	}{
+		{"course", "MATH 220-1", Course{Key: "MATH 220-1"}},
+		{"quoted course", `"MATH 220-1"`, Course{Key: "MATH 220-1"}},
+		{"and binds tighter than or", "MATH 220-1 and MATH 220-2 or MATH 212-1", Any{Children: []Expr{
+			All{Children: []Expr{Course{Key: "MATH 220-1"}, Course{Key: "MATH 220-2"}}},
+			Course{Key: "MATH 212-1"},
+		}}},
+		{"all", "all(COMP_SCI 211-0, COMP_SCI 213-0)", All{Children: []Expr{Course{Key: "COMP_SCI 211-0"}, Course{Key: "COMP_SCI 213-0"}}}},
+		{"n of", "2 of (COMP_SCI 211-0, COMP_SCI 213-0, COMP_SCI 214-0)", Choose{N: 2, Children: []Expr{
+			Course{Key: "COMP_SCI 211-0"}, Course{Key: "COMP_SCI 213-0"}, Course{Key: "COMP_SCI 214-0"},
+		}}},
+		{"n of selector", "3 of COMP_SCI 3xx", Pick{N: 3, Selector: Selector{Subject: "COMP_SCI", MinLevel: 300, MaxLevel: 399}}},
+		{"min level", "2 of COMP_SCI 300+", Pick{N: 2, Selector: Selector{Subject: "COMP_SCI", MinLevel: 300}}},
+		{"level range", "1 of * 200..299", Pick{N: 1, Selector: Selector{Subject: "*", MinLevel: 200, MaxLevel: 299}}},
+		{"bare selector", "PHYSICS *", Pick{N: 1, Selector: Selector{Subject: "PHYSICS"}}},
+		{"units of list", "1.5 units of (MATH 220-1, MATH 220-2)", Units{Min: 1.5, Children: []Expr{Course{Key: "MATH 220-1"}, Course{Key: "MATH 220-2"}}}},
+		{"units of selector", "2 units of ELEC_ENG 3xx", Units{Min: 2, Selector: &Selector{Subject: "ELEC_ENG", MinLevel: 300, MaxLevel: 399}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.src, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.src, got, tt.want)
+			}
+
+			// printing and parsing again gives the same expression
+			again, err := Parse(got.String())
+			if err != nil {
+				t.Fatalf("Parse(%q) of the printed form error: %v", got.String(), err)
+			}
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("round trip of %q gave %#v, want %#v", got.String(), again, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"empty", ""},
+		{"unclosed", "all(MATH 220-1"},
+		{"trailing", "MATH 220-1 MATH 220-2"},
+		{"empty range", "1 of MATH 300..200"},
+		{"overflowing level", "1 of MATH 99999999999999999999+"},
+		{"overflowing range", "1 of MATH 100..99999999999999999999"},
+		{"nan units", "NaN units of MATH 3xx"},
+		{"exponent units", "1e9 units of MATH 3xx"},
+		{"missing of", "2 (MATH 220-1, MATH 220-2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if expr, err := Parse(tt.src); err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.src, expr)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package reqlang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+// Program is a whole major written in the expression language
+//
+//	major "Computer Science"
+//	year 2024
+//	engineering
+//	block "Mathematics": all(MATH 220-1, MATH 220-2, any(MATH 228-1, MATH 230-1))
+//	theme 5
+//	unrestricted 5
+//	unknown 1
+type Program struct {
+	Major         string
+	CatalogYear   int
+	IsEngineering bool
+	Blocks        []Block
+}
+
+// Kind is one of the scraper requirement types, Expr is only set for generic blocks
+type Block struct {
+	Kind            int
+	Name            string
+	Expr            Expr
+	NumRequirements int
+}
+
+const lineWidth = 80
+
+// Format pretty prints an expression, breaking lists over several lines when they get long
+func Format(expr Expr) string {
+	var sb strings.Builder
+	format(&sb, expr, 0)
+	return sb.String()
+}
+
+func format(sb *strings.Builder, expr Expr, indent int) {
+	flat := expr.String()
+	if indent*2+len(flat) <= lineWidth {
+		sb.WriteString(flat)
+		return
+	}
+
+	var head string
+	var children []Expr
+	switch e := expr.(type) {
+	case All:
+		head, children = "all(", e.Children
+	case Any:
+		head, children = "any(", e.Children
+	case Choose:
+		head, children = strconv.Itoa(e.N)+" of (", e.Children
+	case Units:
+		if e.Selector != nil {
+			sb.WriteString(flat)
+			return
+		}
+		head, children = strconv.FormatFloat(e.Min, 'f', -1, 64)+" units of (", e.Children
+	default:
+		sb.WriteString(flat)
+		return
+	}
+
+	pad := strings.Repeat("  ", indent+1)
+	sb.WriteString(head)
+	sb.WriteString("\n")
+	for i, child := range children {
+		sb.WriteString(pad)
+		format(sb, child, indent+1)
+		if i < len(children)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(strings.Repeat("  ", indent))
+	sb.WriteString(")")
+}
+
+func (p Program) String() string {
+	var sb strings.Builder
+	sb.WriteString("major " + strconv.Quote(p.Major) + "\n")
+	if p.CatalogYear != 0 {
+		sb.WriteString("year " + strconv.Itoa(p.CatalogYear) + "\n")
+	}
+	if p.IsEngineering {
+		sb.WriteString("engineering\n")
+	}
+
+	for _, block := range p.Blocks {
+		switch block.Kind {
+		case scraper.GENERIC_REQUIREMENTS:
+			sb.WriteString("block " + strconv.Quote(block.Name) + ": ")
+			format(&sb, block.Expr, 0)
+		case scraper.THEME_REQUIREMENTS:
+			sb.WriteString("theme " + strconv.Itoa(block.NumRequirements))
+		case scraper.UNRESTRICTED_REQUIREMENTS:
+			sb.WriteString("unrestricted " + strconv.Itoa(block.NumRequirements))
+		default:
+			sb.WriteString("unknown " + strconv.Itoa(block.NumRequirements))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func ParseProgram(src string) (*Program, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	if err := p.expectKeyword("major"); err != nil {
+		return nil, err
+	}
+	name, err := p.expect(tokString, "a quoted major name")
+	if err != nil {
+		return nil, err
+	}
+	prog := &Program{Major: name.text, Blocks: []Block{}}
+
+	if p.isKeyword(p.peek(), "year") {
+		p.next()
+		yearTok := p.next()
+		year, err := strconv.Atoi(yearTok.text)
+		if yearTok.kind != tokWord || err != nil {
+			return nil, p.errorf(yearTok, "expected a catalog year")
+		}
+		prog.CatalogYear = year
+	}
+
+	if p.isKeyword(p.peek(), "engineering") {
+		p.next()
+		prog.IsEngineering = true
+	}
+
+	for p.peek().kind != tokEOF {
+		t := p.next()
+		switch {
+		case p.isKeyword(t, "block"):
+			name, err := p.expect(tokString, "a quoted block name")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokColon, "':'"); err != nil {
+				return nil, err
+			}
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			prog.Blocks = append(prog.Blocks, Block{Kind: scraper.GENERIC_REQUIREMENTS, Name: name.text, Expr: expr})
+
+		case p.isKeyword(t, "theme"), p.isKeyword(t, "unrestricted"), p.isKeyword(t, "unknown"):
+			numTok := p.next()
+			n, err := strconv.Atoi(numTok.text)
+			if numTok.kind != tokWord || err != nil {
+				return nil, p.errorf(numTok, "expected a number of courses")
+			}
+			kind := scraper.UNKNOWN_REQUIREMENTS
+			if p.isKeyword(t, "theme") {
+				kind = scraper.THEME_REQUIREMENTS
+			} else if p.isKeyword(t, "unrestricted") {
+				kind = scraper.UNRESTRICTED_REQUIREMENTS
+			}
+			prog.Blocks = append(prog.Blocks, Block{Kind: kind, NumRequirements: n})
+
+		default:
+			return nil, p.errorf(t, "expected block, theme, unrestricted or unknown")
+		}
+	}
+
+	return prog, nil
+}
+
+// FromGeneric writes a block as all(options), an option with several bundles
+// becomes any(bundles) and a bundle with several courses becomes all(courses),
+// ToGeneric relies on exactly this shape to convert back without losing anything
+func FromGeneric(gr scraper.GenericRequirements) Expr {
+	options := make([]Expr, 0, len(gr.Requirements))
+	for _, option := range gr.Requirements {
+		options = append(options, fromOption(option))
+	}
+	return All{Children: options}
+}
+
+func fromOption(option scraper.Option) Expr {
+	if len(option.Between) == 1 {
+		return fromBundle(option.Between[0])
+	}
+	bundles := make([]Expr, 0, len(option.Between))
+	for _, req := range option.Between {
+		bundles = append(bundles, fromBundle(req))
+	}
+	return Any{Children: bundles}
+}
+
+func fromBundle(req scraper.Requirement) Expr {
+	if len(req.Courses) == 1 {
+		return Course{Key: req.Courses[0]}
+	}
+	courses := make([]Expr, 0, len(req.Courses))
+	for _, key := range req.Courses {
+		courses = append(courses, Course{Key: key})
+	}
+	return All{Children: courses}
+}
+
+// ToGeneric converts an expression in the FromGeneric shape back into options,
+// N-of-M, selectors and unit minimums have no equivalent in the json format
+func ToGeneric(name string, expr Expr) (scraper.GenericRequirements, error) {
+	gr := scraper.GenericRequirements{Name: name}
+
+	top, ok := expr.(All)
+	if !ok {
+		top = All{Children: []Expr{expr}}
+	}
+
+	gr.Requirements = make([]scraper.Option, 0, len(top.Children))
+	for _, child := range top.Children {
+		option, err := toOption(child)
+		if err != nil {
+			return gr, fmt.Errorf("block %s: %w", name, err)
+		}
+		gr.Requirements = append(gr.Requirements, option)
+	}
+	return gr, nil
+}
+
+func toOption(expr Expr) (scraper.Option, error) {
+	anyOf, ok := expr.(Any)
+	if !ok {
+		req, err := toBundle(expr)
+		if err != nil {
+			return scraper.Option{}, err
+		}
+		return scraper.Option{Between: []scraper.Requirement{req}}, nil
+	}
+
+	option := scraper.Option{Between: make([]scraper.Requirement, 0, len(anyOf.Children))}
+	for _, child := range anyOf.Children {
+		req, err := toBundle(child)
+		if err != nil {
+			return option, err
+		}
+		option.Between = append(option.Between, req)
+	}
+	return option, nil
+}
+
+func toBundle(expr Expr) (scraper.Requirement, error) {
+	switch e := expr.(type) {
+	case Course:
+		return scraper.Requirement{Courses: []string{e.Key}}, nil
+	case All:
+		req := scraper.Requirement{Courses: make([]string, 0, len(e.Children))}
+		for _, child := range e.Children {
+			course, ok := child.(Course)
+			if !ok {
+				return req, fmt.Errorf("%s cannot be stored as a list of courses", expr)
+			}
+			req.Courses = append(req.Courses, course.Key)
+		}
+		return req, nil
+	}
+	return scraper.Requirement{}, fmt.Errorf("%s cannot be stored as a list of courses", expr)
+}
+
+func FromMajorRequirements(mr *scraper.MajorRequirements) (*Program, error) {
+	prog := &Program{
+		Major:         mr.Major,
+		CatalogYear:   mr.CatalogYear,
+		IsEngineering: mr.IsEngineering,
+		Blocks:        make([]Block, 0, len(mr.AllRequirements)),
+	}
+
+	for _, req := range mr.AllRequirements {
+		switch r := req.(type) {
+		case scraper.GenericRequirements:
+			prog.Blocks = append(prog.Blocks, Block{Kind: r.GetType(), Name: r.Name, Expr: FromGeneric(r)})
+		case scraper.ThemeRequirements:
+			prog.Blocks = append(prog.Blocks, Block{Kind: r.GetType(), NumRequirements: r.NumRequirements})
+		case scraper.UnrestrictedRequirements:
+			prog.Blocks = append(prog.Blocks, Block{Kind: r.GetType(), NumRequirements: r.NumRequirements})
+		case scraper.UnknownRequirements:
+			prog.Blocks = append(prog.Blocks, Block{Kind: r.GetType(), NumRequirements: r.NumRequirements})
+		default:
+			return nil, fmt.Errorf("unsupported requirement block %T", req)
+		}
+	}
+	return prog, nil
+}
+
+func (p Program) ToMajorRequirements() (*scraper.MajorRequirements, error) {
+	mr := &scraper.MajorRequirements{
+		Major:           p.Major,
+		CatalogYear:     p.CatalogYear,
+		IsEngineering:   p.IsEngineering,
+		AllRequirements: make([]scraper.Req, 0, len(p.Blocks)),
+	}
+
+	for _, block := range p.Blocks {
+		switch block.Kind {
+		case scraper.GENERIC_REQUIREMENTS:
+			gr, err := ToGeneric(block.Name, block.Expr)
+			if err != nil {
+				return nil, err
+			}
+			mr.AllRequirements = append(mr.AllRequirements, gr)
+		case scraper.THEME_REQUIREMENTS:
+			mr.AllRequirements = append(mr.AllRequirements, scraper.ThemeRequirements{NumRequirements: block.NumRequirements})
+		case scraper.UNRESTRICTED_REQUIREMENTS:
+			mr.AllRequirements = append(mr.AllRequirements, scraper.UnrestrictedRequirements{NumRequirements: block.NumRequirements})
+		default:
+			mr.AllRequirements = append(mr.AllRequirements, scraper.UnknownRequirements{NumRequirements: block.NumRequirements})
+		}
+	}
+	return mr, nil
+}
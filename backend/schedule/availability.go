@@ -0,0 +1,248 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+// a span of free time on some days, Start and End are times like "12:00PM"
+type Window struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Level is the hundreds of the course number, 300 matches 300 to 399
+type AvailabilityRequest struct {
+	Quarter int      `json:"quarter"`
+	Windows []Window `json:"windows"`
+	Subject string   `json:"subject"`
+	Level   int      `json:"level"`
+}
+
+type AvailableSection struct {
+	Section  SectionRef       `json:"section"`
+	Meetings []ConflictWindow `json:"meetings"`
+}
+
+// Unscheduled counts the sections that matched the filters but have no readable
+// meeting times, they cannot be shown to fit and are left out
+type AvailabilityReport struct {
+	Quarter     int                `json:"quarter"`
+	Sections    []AvailableSection `json:"sections"`
+	Unscheduled int                `json:"unscheduled"`
+}
+
+type indexedMeeting struct {
+	start, end int
+	course     *scraper.Course
+}
+
+type quarterIndex struct {
+	// meetings of every weekday sorted by start time
+	days        [7][]indexedMeeting
+	meetings    map[*scraper.Course][]Meeting
+	unscheduled []*scraper.Course
+}
+
+// MeetingIndex answers which sections meet only inside given time windows without
+// going through every section of a quarter, a meeting fits a window exactly when it
+// starts inside it and ends before it closes so meetings are kept sorted by start
+type MeetingIndex struct {
+	quarters map[int]*quarterIndex
+}
+
+func NewMeetingIndex(store *scraper.CoursesStore) *MeetingIndex {
+	index := &MeetingIndex{quarters: make(map[int]*quarterIndex)}
+	for quarter, courses := range store.CoursesByQuarter {
+		qi := &quarterIndex{meetings: make(map[*scraper.Course][]Meeting)}
+		for _, course := range courses {
+			weekly, issues := SectionMeetings(course)
+			if len(issues) > 0 || len(weekly) == 0 {
+				qi.unscheduled = append(qi.unscheduled, course)
+				continue
+			}
+			qi.meetings[course] = weekly
+			for _, m := range weekly {
+				qi.days[m.Day] = append(qi.days[m.Day], indexedMeeting{start: m.Start, end: m.End, course: course})
+			}
+		}
+
+		for _, meetings := range qi.days {
+			sort.Slice(meetings, func(i, j int) bool { return meetings[i].start < meetings[j].start })
+		}
+		index.quarters[quarter] = qi
+	}
+	return index
+}
+
+type span struct {
+	start, end int
+}
+
+// merged windows of every weekday, overlapping and touching windows become one so a
+// meeting that runs from one into the next still fits
+func parseWindows(windows []Window) ([7][]span, error) {
+	var days [7][]span
+	for _, w := range windows {
+		start, err := scraper.ParseClock(w.Start)
+		if err != nil {
+			return days, fmt.Errorf("invalid start %q", w.Start)
+		}
+		end, err := scraper.ParseClock(w.End)
+		if err != nil {
+			return days, fmt.Errorf("invalid end %q", w.End)
+		}
+		if end <= start {
+			return days, fmt.Errorf("window %s to %s ends before it starts", w.Start, w.End)
+		}
+		if len(w.Days) == 0 {
+			return days, fmt.Errorf("window %s to %s has no days", w.Start, w.End)
+		}
+		for _, name := range w.Days {
+			day, ok := parseDay(name)
+			if !ok {
+				return days, fmt.Errorf("invalid day %q", name)
+			}
+			days[day] = append(days[day], span{start, end})
+		}
+	}
+
+	for day, spans := range days {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+		merged := make([]span, 0, len(spans))
+		for _, s := range spans {
+			if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+				merged[n-1].end = max(merged[n-1].end, s.end)
+				continue
+			}
+			merged = append(merged, s)
+		}
+		days[day] = merged
+	}
+	return days, nil
+}
+
+// fitting returns the sections of a quarter whose every meeting falls inside the
+// windows, windows are merged per weekday so each meeting fits at most one of them
+func (index *MeetingIndex) fitting(quarter int, windows [7][]span) []*scraper.Course {
+	qi, found := index.quarters[quarter]
+	if !found {
+		return nil
+	}
+
+	fits := make(map[*scraper.Course]int)
+	for day, spans := range windows {
+		meetings := qi.days[day]
+		for _, s := range spans {
+			i := sort.Search(len(meetings), func(i int) bool { return meetings[i].start >= s.start })
+			for ; i < len(meetings) && meetings[i].start < s.end; i++ {
+				if meetings[i].end <= s.end {
+					fits[meetings[i].course]++
+				}
+			}
+		}
+	}
+
+	courses := make([]*scraper.Course, 0)
+	for course, count := range fits {
+		if count == len(qi.meetings[course]) {
+			courses = append(courses, course)
+		}
+	}
+	return courses
+}
+
+func matches(course *scraper.Course, subject string, level int) bool {
+	if subject != "" && !strings.EqualFold(course.Subject, subject) {
+		return false
+	}
+	if level == 0 {
+		return true
+	}
+	number, err := strconv.Atoi(strings.SplitN(course.Number, "-", 2)[0])
+	return err == nil && number/100 == level/100
+}
+
+func (index *MeetingIndex) Available(req AvailabilityRequest) (*AvailabilityReport, error) {
+	windows, err := parseWindows(req.Windows)
+	if err != nil {
+		return nil, err
+	}
+	if req.Level < 0 || req.Level >= 1000 || req.Level%100 != 0 {
+		return nil, fmt.Errorf("invalid level %d", req.Level)
+	}
+	subject := strings.TrimSpace(req.Subject)
+
+	report := &AvailabilityReport{Quarter: req.Quarter, Sections: []AvailableSection{}}
+	qi, found := index.quarters[req.Quarter]
+	if !found {
+		return report, nil
+	}
+
+	for _, course := range index.fitting(req.Quarter, windows) {
+		if !matches(course, subject, req.Level) {
+			continue
+		}
+		meetings := qi.meetings[course]
+		section := AvailableSection{Section: Ref(course), Meetings: make([]ConflictWindow, 0, len(meetings))}
+		for _, m := range meetings {
+			section.Meetings = append(section.Meetings, ConflictWindow{Day: m.Day.String(), Start: FormatMinutes(m.Start), End: FormatMinutes(m.End)})
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	for _, course := range qi.unscheduled {
+		if matches(course, subject, req.Level) {
+			report.Unscheduled++
+		}
+	}
+
+	sort.Slice(report.Sections, func(i, j int) bool {
+		a, b := report.Sections[i].Section, report.Sections[j].Section
+		if a.Course != b.Course {
+			return a.Course < b.Course
+		}
+		return a.Section < b.Section
+	})
+	return report, nil
+}
+
+func AvailabilityHandler(index *MeetingIndex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AvailabilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Quarter == 0 {
+			http.Error(w, "Quarter parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Windows) == 0 {
+			http.Error(w, "Windows are required", http.StatusBadRequest)
+			return
+		}
+
+		if _, found := index.quarters[req.Quarter]; !found {
+			http.Error(w, "Quarter not found", http.StatusNotFound)
+			return
+		}
+
+		report, err := index.Available(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
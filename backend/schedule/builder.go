@@ -0,0 +1,341 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+const (
+	DEFAULT_SCHEDULES = 10
+	MAX_SCHEDULES     = 50
+	// section combinations tried before the search gives up on finding better ones
+	DEFAULT_BUDGET = 100000
+	MAX_BUDGET     = 1000000
+)
+
+// penalties are in minutes so every preference weighs against the others on the same scale
+const (
+	FREE_DAY_PENALTY  = 120
+	INSTRUCTOR_BONUS  = 60
+	EARLY_MINUTE_COST = 1
+	GAP_MINUTE_COST   = 1
+)
+
+// EarliestStart is a time like "10:00AM", meetings before it count against a schedule
+type Preferences struct {
+	EarliestStart string   `json:"earliestStart"`
+	FreeDays      []string `json:"freeDays"`
+	Compact       bool     `json:"compact"`
+	Instructors   []string `json:"instructors"`
+}
+
+type BuildRequest struct {
+	Quarter     int         `json:"quarter"`
+	Courses     []string    `json:"courses"`
+	Limit       int         `json:"limit"`
+	Budget      int         `json:"budget"`
+	Preferences Preferences `json:"preferences"`
+}
+
+type ScoreBreakdown struct {
+	EarlyMinutes         int      `json:"earlyMinutes"`
+	BusyFreeDays         []string `json:"busyFreeDays"`
+	GapMinutes           int      `json:"gapMinutes"`
+	PreferredInstructors int      `json:"preferredInstructors"`
+}
+
+// a lower score is a better schedule
+type BuiltSchedule struct {
+	Sections   []SectionRef   `json:"sections"`
+	Score      int            `json:"score"`
+	Breakdown  ScoreBreakdown `json:"breakdown"`
+	TimeIssues []TimeIssue    `json:"timeIssues"`
+}
+
+// Complete is false when the budget ran out before every combination was tried
+type BuildReport struct {
+	Quarter     int             `json:"quarter"`
+	Schedules   []BuiltSchedule `json:"schedules"`
+	Unavailable []string        `json:"unavailable"`
+	Explored    int             `json:"explored"`
+	Complete    bool            `json:"complete"`
+}
+
+type candidate struct {
+	course    *scraper.Course
+	meetings  []Meeting
+	issues    []TimeIssue
+	preferred bool
+}
+
+type preferences struct {
+	earliest    int
+	freeDays    []time.Weekday
+	compact     bool
+	instructors []string
+}
+
+// parseDay reads a day like "Tue", "thurs" or "Monday"
+func parseDay(name string) (time.Weekday, bool) {
+	if name = strings.TrimSpace(name); name == "" {
+		return 0, false
+	}
+	day, ok := weekdays[scraper.ParseDay(name)]
+	if !ok {
+		day, ok = weekdays[strings.ToUpper(name[:1])+strings.ToLower(name[1:])]
+	}
+	return day, ok
+}
+
+func parsePreferences(p Preferences) (preferences, error) {
+	prefs := preferences{compact: p.Compact}
+	if p.EarliestStart != "" {
+		earliest, err := scraper.ParseClock(p.EarliestStart)
+		if err != nil {
+			return prefs, fmt.Errorf("invalid earliest start %q", p.EarliestStart)
+		}
+		prefs.earliest = earliest
+	}
+	for _, name := range p.FreeDays {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		day, ok := parseDay(name)
+		if !ok {
+			return prefs, fmt.Errorf("invalid free day %q", name)
+		}
+		prefs.freeDays = append(prefs.freeDays, day)
+	}
+	for _, name := range p.Instructors {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			prefs.instructors = append(prefs.instructors, name)
+		}
+	}
+	return prefs, nil
+}
+
+func (p preferences) teaches(course *scraper.Course) bool {
+	for _, instructor := range course.Instructors {
+		for _, name := range p.instructors {
+			if strings.Contains(strings.ToLower(instructor.Name), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p preferences) score(chosen []*candidate) (int, ScoreBreakdown) {
+	breakdown := ScoreBreakdown{BusyFreeDays: []string{}}
+	byDay := make(map[time.Weekday][]Meeting)
+	for _, c := range chosen {
+		if c.preferred {
+			breakdown.PreferredInstructors++
+		}
+		for _, m := range c.meetings {
+			byDay[m.Day] = append(byDay[m.Day], m)
+			if p.earliest > 0 && m.Start < p.earliest {
+				breakdown.EarlyMinutes += p.earliest - m.Start
+			}
+		}
+	}
+
+	for _, day := range p.freeDays {
+		if len(byDay[day]) > 0 {
+			breakdown.BusyFreeDays = append(breakdown.BusyFreeDays, day.String())
+		}
+	}
+
+	if p.compact {
+		for _, meetings := range byDay {
+			sort.Slice(meetings, func(i, j int) bool { return meetings[i].Start < meetings[j].Start })
+			end := meetings[0].End
+			for _, m := range meetings[1:] {
+				if m.Start > end {
+					breakdown.GapMinutes += m.Start - end
+				}
+				end = max(end, m.End)
+			}
+		}
+	}
+
+	score := breakdown.EarlyMinutes*EARLY_MINUTE_COST +
+		len(breakdown.BusyFreeDays)*FREE_DAY_PENALTY +
+		breakdown.GapMinutes*GAP_MINUTE_COST -
+		breakdown.PreferredInstructors*INSTRUCTOR_BONUS
+	return score, breakdown
+}
+
+func conflicts(a, b []Meeting) bool {
+	for _, ma := range a {
+		for _, mb := range b {
+			if ma.Day == mb.Day && max(ma.Start, mb.Start) < min(ma.End, mb.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuildSchedules tries section combinations of the wanted courses and their required
+// components depth first and keeps the best conflict free ones, courses with the fewest
+// sections are chosen first
+func BuildSchedules(store *scraper.CoursesStore, req BuildRequest) (*BuildReport, error) {
+	prefs, err := parsePreferences(req.Preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DEFAULT_SCHEDULES
+	}
+	limit = min(limit, MAX_SCHEDULES)
+
+	budget := req.Budget
+	if budget <= 0 {
+		budget = DEFAULT_BUDGET
+	}
+	budget = min(budget, MAX_BUDGET)
+
+	report := &BuildReport{Quarter: req.Quarter, Schedules: []BuiltSchedule{}, Unavailable: []string{}}
+
+	wanted := make([]string, 0, len(req.Courses))
+	for _, key := range req.Courses {
+		if key = strings.ToUpper(strings.TrimSpace(key)); key != "" && !slices.Contains(wanted, key) {
+			wanted = append(wanted, key)
+		}
+	}
+
+	newCandidate := func(course *scraper.Course) *candidate {
+		meetings, issues := SectionMeetings(course)
+		return &candidate{course: course, meetings: meetings, issues: issues, preferred: prefs.teaches(course)}
+	}
+
+	groups := make([][]*candidate, 0, len(wanted))
+	for _, key := range wanted {
+		group := make([]*candidate, 0)
+		for _, course := range store.GetCoursesByKey(key) {
+			if course.Quarter == req.Quarter {
+				group = append(group, newCandidate(course))
+			}
+		}
+
+		if len(group) == 0 {
+			report.Unavailable = append(report.Unavailable, key)
+			continue
+		}
+		groups = append(groups, group)
+
+		// one section of every required component, unless it was asked for by itself
+		components := store.GetComponents(key, req.Quarter)
+		for _, component := range scraper.REQUIRED_COMPONENTS {
+			sub := make([]*candidate, 0)
+			for _, course := range components[component] {
+				if !slices.Contains(wanted, scraper.GetCourseKey(*course)) {
+					sub = append(sub, newCandidate(course))
+				}
+			}
+			if len(sub) > 0 {
+				groups = append(groups, sub)
+			}
+		}
+	}
+
+	if len(groups) == 0 {
+		report.Complete = true
+		return report, nil
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i]) < len(groups[j]) })
+
+	chosen := make([]*candidate, 0, len(groups))
+	var search func(depth int) bool
+	search = func(depth int) bool {
+		if report.Explored >= budget {
+			return false
+		}
+		report.Explored++
+
+		if depth == len(groups) {
+			score, breakdown := prefs.score(chosen)
+			if len(report.Schedules) == limit && score >= report.Schedules[limit-1].Score {
+				return true
+			}
+
+			built := BuiltSchedule{Sections: make([]SectionRef, 0, len(chosen)), Score: score, Breakdown: breakdown, TimeIssues: []TimeIssue{}}
+			for _, c := range chosen {
+				built.Sections = append(built.Sections, Ref(c.course))
+				built.TimeIssues = append(built.TimeIssues, c.issues...)
+			}
+			at := sort.Search(len(report.Schedules), func(i int) bool { return report.Schedules[i].Score > score })
+			report.Schedules = slices.Insert(report.Schedules, at, built)
+			if len(report.Schedules) > limit {
+				report.Schedules = report.Schedules[:limit]
+			}
+			return true
+		}
+
+	next:
+		for _, c := range groups[depth] {
+			for _, other := range chosen {
+				if conflicts(c.meetings, other.meetings) {
+					continue next
+				}
+			}
+			chosen = append(chosen, c)
+			ok := search(depth + 1)
+			chosen = chosen[:len(chosen)-1]
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	report.Complete = search(0)
+	return report, nil
+}
+
+func BuildSchedulesHandler(store *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BuildRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Quarter == 0 {
+			http.Error(w, "Quarter parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Courses) == 0 {
+			http.Error(w, "Courses are required", http.StatusBadRequest)
+			return
+		}
+
+		courses, ambiguous := store.ResolveKeys(req.Courses)
+		if len(ambiguous) > 0 {
+			scraper.WriteAmbiguousKeys(w, ambiguous)
+			return
+		}
+		req.Courses = courses
+
+		report, err := BuildSchedules(store, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
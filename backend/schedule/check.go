@@ -0,0 +1,341 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+)
+
+const (
+	// a lecture was picked without one of its required components, or a component without its lecture
+	COMPONENT_MISSING = "missing"
+	// more than one section of the same component was picked for a course
+	COMPONENT_DUPLICATE = "duplicate"
+)
+
+const (
+	// the section has no meeting times yet
+	TIME_TBA = "tba"
+	// the meeting time was scraped but its days or times could not be read
+	TIME_UNPARSEABLE = "unparseable"
+)
+
+var weekdays = map[string]time.Weekday{
+	"Sunday":    time.Sunday,
+	"Monday":    time.Monday,
+	"Tuesday":   time.Tuesday,
+	"Wednesday": time.Wednesday,
+	"Thursday":  time.Thursday,
+	"Friday":    time.Friday,
+	"Saturday":  time.Saturday,
+}
+
+// sections are looked up by their class number or by their class descriptions url
+type CheckRequest struct {
+	Quarter  int      `json:"quarter"`
+	Sections []int    `json:"sections"`
+	URLs     []string `json:"urls"`
+}
+
+type SectionRef struct {
+	Section   int    `json:"section"`
+	URL       string `json:"url"`
+	Course    string `json:"course"`
+	Title     string `json:"title"`
+	Component string `json:"component,omitempty"`
+}
+
+// Course is the lecture the component belongs to
+type ComponentIssue struct {
+	Course    string `json:"course"`
+	Component string `json:"component"`
+	Kind      string `json:"kind"`
+	Sections  []int  `json:"sections"`
+}
+
+// Start and End are minutes after midnight
+type Meeting struct {
+	Day      time.Weekday
+	Start    int
+	End      int
+	Location string
+}
+
+type TimeIssue struct {
+	Section   SectionRef `json:"section"`
+	Kind      string     `json:"kind"`
+	TimeRange string     `json:"timeRange,omitempty"`
+	Message   string     `json:"message"`
+}
+
+type ConflictWindow struct {
+	Day   string `json:"day"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type Conflict struct {
+	Sections [2]SectionRef    `json:"sections"`
+	Windows  []ConflictWindow `json:"windows"`
+}
+
+type CheckReport struct {
+	Quarter      int              `json:"quarter"`
+	ConflictFree bool             `json:"conflictFree"`
+	Sections     []SectionRef     `json:"sections"`
+	Conflicts    []Conflict       `json:"conflicts"`
+	TimeIssues   []TimeIssue      `json:"timeIssues"`
+	Components   []ComponentIssue `json:"components"`
+	NotFound     []string         `json:"notFound"`
+}
+
+func Ref(course *scraper.Course) SectionRef {
+	return SectionRef{Section: course.Section, URL: course.URL, Course: scraper.GetCourseKey(*course), Title: course.Title, Component: course.Component}
+}
+
+func minutes(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func FormatMinutes(m int) string {
+	return time.Date(0, 1, 1, m/60, m%60, 0, 0, time.UTC).Format("3:04PM")
+}
+
+// midterm and final slots are scraped like meeting times but do not repeat weekly
+func isExam(location string) bool {
+	location = strings.ToLower(location)
+	return strings.Contains(location, "midterm") || strings.Contains(location, "final exam")
+}
+
+// SectionMeetings splits the weekly meeting times of a section into one meeting per
+// day, meeting times without a usable day and time range are returned as issues and
+// exam slots are left out
+func SectionMeetings(course *scraper.Course) ([]Meeting, []TimeIssue) {
+	meetings := make([]Meeting, 0)
+	issues := make([]TimeIssue, 0)
+	issue := func(kind, timeRange, message string) {
+		issues = append(issues, TimeIssue{Section: Ref(course), Kind: kind, TimeRange: timeRange, Message: message})
+	}
+
+	if len(course.MeetingTimes) == 0 {
+		issue(TIME_TBA, "", "section has no meeting times")
+		return meetings, issues
+	}
+
+	for _, mt := range course.MeetingTimes {
+		if isExam(mt.Location) {
+			continue
+		}
+		if mt.StartTime.IsZero() && mt.EndTime.IsZero() && len(mt.Days) == 0 {
+			if strings.EqualFold(strings.TrimSpace(mt.TimeRange), "TBA") || mt.TimeRange == "" {
+				issue(TIME_TBA, mt.TimeRange, "meeting time is to be announced")
+			} else {
+				issue(TIME_UNPARSEABLE, mt.TimeRange, "meeting time could not be read")
+			}
+			continue
+		}
+
+		start, end := minutes(mt.StartTime), minutes(mt.EndTime)
+		if mt.StartTime.IsZero() || mt.EndTime.IsZero() || end <= start {
+			issue(TIME_UNPARSEABLE, mt.TimeRange, "meeting time has no complete time range")
+			continue
+		}
+		if len(mt.Days) == 0 {
+			issue(TIME_UNPARSEABLE, mt.TimeRange, "meeting time has no days")
+			continue
+		}
+
+		for _, name := range mt.Days {
+			day, ok := weekdays[name]
+			if !ok {
+				issue(TIME_UNPARSEABLE, mt.TimeRange, fmt.Sprintf("%q is not a day", name))
+				continue
+			}
+			meetings = append(meetings, Meeting{Day: day, Start: start, End: end, Location: mt.Location})
+		}
+	}
+	return meetings, issues
+}
+
+// Overlap lists every day and time window in which both sets of meetings meet
+func Overlap(a, b []Meeting) []ConflictWindow {
+	type window struct {
+		day        time.Weekday
+		start, end int
+	}
+	windows := make([]window, 0)
+	for _, ma := range a {
+		for _, mb := range b {
+			start, end := max(ma.Start, mb.Start), min(ma.End, mb.End)
+			if ma.Day != mb.Day || start >= end {
+				continue
+			}
+			w := window{ma.Day, start, end}
+			if !slices.Contains(windows, w) {
+				windows = append(windows, w)
+			}
+		}
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		if windows[i].day != windows[j].day {
+			return windows[i].day < windows[j].day
+		}
+		return windows[i].start < windows[j].start
+	})
+
+	result := make([]ConflictWindow, 0, len(windows))
+	for _, w := range windows {
+		result = append(result, ConflictWindow{Day: w.day.String(), Start: FormatMinutes(w.start), End: FormatMinutes(w.end)})
+	}
+	return result
+}
+
+// CheckComponents makes sure every picked lecture comes with exactly one section of
+// each of its required components and every picked component with its lecture
+func CheckComponents(store *scraper.CoursesStore, quarter int, courses []*scraper.Course) []ComponentIssue {
+	issues := make([]ComponentIssue, 0)
+	picked := func(key, component string, candidates []*scraper.Course) {
+		sections := make([]int, 0)
+		for _, course := range courses {
+			if slices.Contains(candidates, course) {
+				sections = append(sections, course.Section)
+			}
+		}
+		switch {
+		case len(sections) == 0:
+			issues = append(issues, ComponentIssue{Course: key, Component: component, Kind: COMPONENT_MISSING, Sections: sections})
+		case len(sections) > 1:
+			issues = append(issues, ComponentIssue{Course: key, Component: component, Kind: COMPONENT_DUPLICATE, Sections: sections})
+		}
+	}
+
+	lectures := make([]string, 0)
+	for _, course := range courses {
+		if key := scraper.GetCourseKey(*course); course.Parent == "" && !slices.Contains(lectures, key) {
+			lectures = append(lectures, key)
+		}
+	}
+
+	for _, key := range lectures {
+		sections := make([]*scraper.Course, 0)
+		for _, course := range store.GetCoursesByKey(key) {
+			if course.Quarter == quarter {
+				sections = append(sections, course)
+			}
+		}
+		picked(key, scraper.COMPONENT_LECTURE, sections)
+
+		components := store.GetComponents(key, quarter)
+		for _, component := range scraper.REQUIRED_COMPONENTS {
+			if len(components[component]) > 0 {
+				picked(key, component, components[component])
+			}
+		}
+	}
+
+	// a sub-section only makes sense next to its lecture
+	for _, course := range courses {
+		if course.Parent != "" && !slices.Contains(lectures, course.Parent) {
+			issues = append(issues, ComponentIssue{Course: course.Parent, Component: scraper.COMPONENT_LECTURE, Kind: COMPONENT_MISSING, Sections: []int{}})
+			lectures = append(lectures, course.Parent)
+		}
+	}
+	return issues
+}
+
+// CheckSections reports every pair of sections that meet at the same time, sections
+// with TBA or unreadable meeting times are checked with the meetings that could be read
+func CheckSections(quarter int, courses []*scraper.Course) *CheckReport {
+	report := &CheckReport{
+		Quarter:    quarter,
+		Sections:   make([]SectionRef, 0, len(courses)),
+		Conflicts:  []Conflict{},
+		TimeIssues: []TimeIssue{},
+		Components: []ComponentIssue{},
+		NotFound:   []string{},
+	}
+
+	meetings := make([][]Meeting, len(courses))
+	for i, course := range courses {
+		report.Sections = append(report.Sections, Ref(course))
+		var issues []TimeIssue
+		meetings[i], issues = SectionMeetings(course)
+		report.TimeIssues = append(report.TimeIssues, issues...)
+	}
+
+	for i := range courses {
+		for j := i + 1; j < len(courses); j++ {
+			if windows := Overlap(meetings[i], meetings[j]); len(windows) > 0 {
+				report.Conflicts = append(report.Conflicts, Conflict{
+					Sections: [2]SectionRef{report.Sections[i], report.Sections[j]},
+					Windows:  windows,
+				})
+			}
+		}
+	}
+
+	report.ConflictFree = len(report.Conflicts) == 0
+	return report
+}
+
+// FindSections looks up sections of a quarter, the ids and urls that match nothing
+// are returned as written
+func FindSections(store *scraper.CoursesStore, quarter int, sections []int, urls []string) ([]*scraper.Course, []string) {
+	found := make([]*scraper.Course, 0, len(sections)+len(urls))
+	notFound := make([]string, 0)
+	add := func(match func(*scraper.Course) bool, ref string) {
+		i := slices.IndexFunc(store.GetCoursesByQuarter(quarter), match)
+		if i == -1 {
+			notFound = append(notFound, ref)
+			return
+		}
+		if course := store.GetCoursesByQuarter(quarter)[i]; !slices.Contains(found, course) {
+			found = append(found, course)
+		}
+	}
+
+	for _, section := range sections {
+		add(func(c *scraper.Course) bool { return c.Section == section }, strconv.Itoa(section))
+	}
+	for _, url := range urls {
+		url = strings.TrimRight(strings.TrimSpace(url), "/")
+		add(func(c *scraper.Course) bool { return strings.TrimRight(c.URL, "/") == url }, url)
+	}
+	return found, notFound
+}
+
+func CheckScheduleHandler(store *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Quarter == 0 {
+			http.Error(w, "Quarter parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Sections) == 0 && len(req.URLs) == 0 {
+			http.Error(w, "Sections or urls are required", http.StatusBadRequest)
+			return
+		}
+
+		courses, notFound := FindSections(store, req.Quarter, req.Sections, req.URLs)
+		report := CheckSections(req.Quarter, courses)
+		report.Components = CheckComponents(store, req.Quarter, courses)
+		report.NotFound = notFound
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
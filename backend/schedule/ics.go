@@ -0,0 +1,227 @@
+package schedule
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nynniaw12/ieee-planner/scraper"
+	"github.com/nynniaw12/ieee-planner/term"
+)
+
+// the VTIMEZONE calendar apps need to read TZID=America/Chicago, US rules since 2007
+const icsTimezone = `BEGIN:VTIMEZONE
+TZID:America/Chicago
+BEGIN:DAYLIGHT
+TZOFFSETFROM:-0600
+TZOFFSETTO:-0500
+TZNAME:CDT
+DTSTART:19700308T020000
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU
+END:DAYLIGHT
+BEGIN:STANDARD
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0600
+TZNAME:CST
+DTSTART:19701101T020000
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU
+END:STANDARD
+END:VTIMEZONE`
+
+var icsDays = map[time.Weekday]string{
+	time.Sunday:    "SU",
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// lines longer than 75 octets are folded onto continuation lines starting with a space
+func foldLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+type icsWriter struct {
+	b strings.Builder
+}
+
+func (w *icsWriter) line(format string, args ...any) {
+	w.b.WriteString(foldLine(fmt.Sprintf(format, args...)))
+	w.b.WriteString("\r\n")
+}
+
+// ScheduleICS writes one weekly recurring event per meeting time of every section,
+// sections that are TBA or whose times could not be read are left out
+func ScheduleICS(quarter int, courses []*scraper.Course, start, end time.Time, now time.Time) string {
+	loc := start.Location()
+	w := &icsWriter{}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//ieee-planner//schedule//EN")
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	w.line("X-WR-CALNAME:%s", escapeText(fmt.Sprintf("Schedule %d", quarter)))
+	w.line("X-WR-TIMEZONE:%s", term.TIMEZONE)
+	for _, line := range strings.Split(icsTimezone, "\n") {
+		w.line("%s", line)
+	}
+
+	// the last day of classes is included
+	until := time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, loc).UTC()
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	for _, course := range courses {
+		instructors := make([]string, 0, len(course.Instructors))
+		for _, instructor := range course.Instructors {
+			if instructor.Name != "" {
+				instructors = append(instructors, instructor.Name)
+			}
+		}
+
+		for i, mt := range course.MeetingTimes {
+			if isExam(mt.Location) || mt.StartTime.IsZero() || mt.EndTime.IsZero() {
+				continue
+			}
+
+			days := make([]string, 0, len(mt.Days))
+			first := time.Time{}
+			for _, name := range mt.Days {
+				day, ok := weekdays[name]
+				if !ok {
+					continue
+				}
+				days = append(days, icsDays[day])
+
+				offset := (int(day) - int(start.Weekday()) + 7) % 7
+				if date := start.AddDate(0, 0, offset); first.IsZero() || date.Before(first) {
+					first = date
+				}
+			}
+			if len(days) == 0 {
+				continue
+			}
+
+			begin := time.Date(first.Year(), first.Month(), first.Day(), mt.StartTime.Hour(), mt.StartTime.Minute(), 0, 0, loc)
+			finish := time.Date(first.Year(), first.Month(), first.Day(), mt.EndTime.Hour(), mt.EndTime.Minute(), 0, 0, loc)
+
+			description := []string{fmt.Sprintf("Section %d", course.Section)}
+			if mt.Location != "" {
+				description = append(description, "Location: "+mt.Location)
+			}
+			if len(instructors) > 0 {
+				description = append(description, "Instructor: "+strings.Join(instructors, ", "))
+			}
+
+			w.line("BEGIN:VEVENT")
+			w.line("UID:%d-%d-%d@ieee-planner", quarter, course.Section, i)
+			w.line("DTSTAMP:%s", stamp)
+			w.line("DTSTART;TZID=%s:%s", term.TIMEZONE, begin.Format("20060102T150405"))
+			w.line("DTEND;TZID=%s:%s", term.TIMEZONE, finish.Format("20060102T150405"))
+			w.line("RRULE:FREQ=WEEKLY;BYDAY=%s;UNTIL=%s", strings.Join(days, ","), until.Format("20060102T150405Z"))
+			w.line("SUMMARY:%s", escapeText(scraper.GetCourseKey(*course)+" "+course.Title))
+			if mt.Location != "" {
+				w.line("LOCATION:%s", escapeText(mt.Location))
+			}
+			w.line("DESCRIPTION:%s", escapeText(strings.Join(description, "\n")))
+			if course.URL != "" {
+				w.line("URL:%s", course.URL)
+			}
+			w.line("END:VEVENT")
+		}
+	}
+
+	w.line("END:VCALENDAR")
+	return w.b.String()
+}
+
+// sections come as a comma separated list or repeated, start and end override the
+// estimated first and last day of classes
+func ScheduleICSHandler(store *scraper.CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		quarterStr := query.Get("quarter")
+		if quarterStr == "" {
+			http.Error(w, "Quarter parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		quarter, err := strconv.Atoi(quarterStr)
+		if err != nil {
+			http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+			return
+		}
+
+		var sections []int
+		for _, param := range query["sections"] {
+			for _, field := range strings.Split(param, ",") {
+				if field = strings.TrimSpace(field); field == "" {
+					continue
+				}
+				section, err := strconv.Atoi(field)
+				if err != nil {
+					http.Error(w, "Invalid sections format", http.StatusBadRequest)
+					return
+				}
+				sections = append(sections, section)
+			}
+		}
+		urls := query["urls"]
+
+		if len(sections) == 0 && len(urls) == 0 {
+			http.Error(w, "Sections or urls are required", http.StatusBadRequest)
+			return
+		}
+
+		t, err := term.FromCode(quarter)
+		if err != nil {
+			http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+			return
+		}
+
+		start, end, _ := t.Dates()
+		loc := start.Location()
+		for param, date := range map[string]*time.Time{"start": &start, "end": &end} {
+			if value := query.Get(param); value != "" {
+				parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("Invalid %s format", param), http.StatusBadRequest)
+					return
+				}
+				*date = parsed
+			}
+		}
+		if end.Before(start) {
+			http.Error(w, "End is before start", http.StatusBadRequest)
+			return
+		}
+
+		courses, notFound := FindSections(store, quarter, sections, urls)
+		if len(notFound) > 0 {
+			http.Error(w, fmt.Sprintf("Sections not found: %s", strings.Join(notFound, ", ")), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"schedule-%d.ics\"", quarter))
+		fmt.Fprint(w, ScheduleICS(quarter, courses, start, end, time.Now()))
+	}
+}
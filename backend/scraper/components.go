@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// codes used in section numbers like "215-SG-2-02", lectures are the default
+const (
+	COMPONENT_LECTURE     = "LEC"
+	COMPONENT_LAB         = "LAB"
+	COMPONENT_DISCUSSION  = "DIS"
+	COMPONENT_STUDY_GROUP = "SG"
+	COMPONENT_MENTORED    = "MG"
+)
+
+// a schedule needs one section of every required component of a course, study
+// groups and mentored study are optional extras
+var REQUIRED_COMPONENTS = []string{COMPONENT_LECTURE, COMPONENT_LAB, COMPONENT_DISCUSSION}
+
+var (
+	// "220-SG-2-04" is a study group of 220-2
+	componentNumber = regexp.MustCompile(`^(\d{3})-([A-Z]{2,3})-([0-9A-Z]+)-([0-9A-Z]+)$`)
+	labTitle        = regexp.MustCompile(`(?i)\b(laboratory|lab)\b`)
+	discussionTitle = regexp.MustCompile(`(?i)\b(discussion|recitation)\b`)
+)
+
+func IsRequiredComponent(component string) bool {
+	return slices.Contains(REQUIRED_COMPONENTS, component)
+}
+
+// LinkComponent works out what kind of section a course is and which course it
+// belongs to, sub-sections numbered after their parent are linked by the number and
+// labs and discussions listed separately by the corequisite they are taken with
+func LinkComponent(course *Course) {
+	course.Component = COMPONENT_LECTURE
+	course.Parent = ""
+
+	if m := componentNumber.FindStringSubmatch(course.Number); m != nil && (m[2] == COMPONENT_STUDY_GROUP || m[2] == COMPONENT_MENTORED || m[2] == COMPONENT_LAB || m[2] == COMPONENT_DISCUSSION) {
+		course.Component = m[2]
+		course.Parent = strings.ToUpper(course.Subject + " " + m[1] + "-" + m[3])
+		return
+	}
+
+	switch {
+	case labTitle.MatchString(course.Title):
+		course.Component = COMPONENT_LAB
+	case discussionTitle.MatchString(course.Title):
+		course.Component = COMPONENT_DISCUSSION
+	default:
+		return
+	}
+
+	// a lab that has to be taken with exactly one lecture course hangs off it
+	if course.Requisites != nil {
+		if parents := course.Requisites.Corequisites.Courses(); len(parents) == 1 && parents[0] != GetCourseKey(*course) {
+			course.Parent = parents[0]
+		}
+	}
+}
+
+// LinkComponents indexes sub-sections by the course they belong to, a lecture whose
+// corequisite is a lab gets the lab's sections as well since the lab may serve several
+// lectures, labs and discussions that belong to nothing are courses of their own
+func (cs *CoursesStore) LinkComponents() {
+	cs.ComponentsByKey = make(map[string][]*Course)
+	link := func(parent string, course *Course) {
+		if !slices.Contains(cs.ComponentsByKey[parent], course) {
+			cs.ComponentsByKey[parent] = append(cs.ComponentsByKey[parent], course)
+		}
+	}
+
+	for _, sections := range cs.CoursesByKey {
+		for _, course := range sections {
+			if course.Parent != "" {
+				link(course.Parent, course)
+			}
+		}
+	}
+
+	parents := make(map[*Course][]string)
+	for key, sections := range cs.CoursesByKey {
+		reqs := latestRequisites(sections)
+		if reqs == nil || sections[0].Component != COMPONENT_LECTURE {
+			continue
+		}
+		for _, coreq := range reqs.Corequisites.Courses() {
+			for _, course := range cs.CoursesByKey[coreq] {
+				if course.Component == COMPONENT_LAB || course.Component == COMPONENT_DISCUSSION {
+					link(key, course)
+					parents[course] = append(parents[course], key)
+				}
+			}
+		}
+	}
+	for course, keys := range parents {
+		if course.Parent == "" && len(keys) == 1 {
+			course.Parent = keys[0]
+		}
+	}
+
+	linked := make(map[*Course]bool)
+	for _, components := range cs.ComponentsByKey {
+		for _, course := range components {
+			linked[course] = true
+		}
+	}
+	for _, sections := range cs.CoursesByKey {
+		for _, course := range sections {
+			if IsRequiredComponent(course.Component) && !linked[course] {
+				course.Component = COMPONENT_LECTURE
+			}
+		}
+	}
+}
+
+// sub-sections of a course in a quarter, grouped by component
+func (cs *CoursesStore) GetComponents(key string, quarter int) map[string][]*Course {
+	components := make(map[string][]*Course)
+	for _, course := range cs.ComponentsByKey[key] {
+		if course.Quarter == quarter {
+			components[course.Component] = append(components[course.Component], course)
+		}
+	}
+	return components
+}
@@ -42,7 +42,8 @@ func ReadCourseDataFromJSON(filePath string) ([]*Course, error) {
 		return nil, fmt.Errorf("error unmarshaling json data: %w", err)
 	}
 
-	fmt.Printf("read %d courses from %s\n", len(courses), filePath)
+	// progress goes to stderr so commands can keep stdout for their output
+	fmt.Fprintf(os.Stderr, "read %d courses from %s\n", len(courses), filePath)
 	return courses, nil
 }
 
@@ -342,6 +343,11 @@ type Course struct {
 	Subject      string        `json:"subject"`
 	School       string        `json:"school"`
 	Quarter      int           `json:"quarter"`
+	Requisites   *Requisites   `json:"requisites,omitempty"`
+	// lecture, lab, discussion or study group, and the course key of the lecture a
+	// sub-section belongs to
+	Component string `json:"component,omitempty"`
+	Parent    string `json:"parent,omitempty"`
 }
 
 // standard days
@@ -365,6 +371,15 @@ func ParseDay(day string) string {
 	return day
 }
 
+// ParseClock reads a time of day like "10:00AM" or "1:30 pm" as minutes after midnight
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("3:04PM", strings.ToUpper(strings.ReplaceAll(s, " ", "")))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
 // structured times
 func ParseTimeRange(timeStr string) (time.Time, time.Time, string) {
 	parts := strings.Split(timeStr, "-")
@@ -750,6 +765,12 @@ func ScrapeNorthwesternCourses(
 
 	c.Wait()
 
+	// the overview and subject come from different handlers so requisites and components are worked out once both are in
+	for _, course := range coursesByURL {
+		course.Requisites = ParseRequisites(course.Overview, course.Subject)
+		LinkComponent(course)
+	}
+
 	return coursesByURL
 }
 
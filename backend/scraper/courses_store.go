@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/nynniaw12/ieee-planner/page"
+	"github.com/nynniaw12/ieee-planner/term"
 )
 
 func GetCourseKey(c Course) string {
@@ -30,6 +34,13 @@ type CoursesStore struct {
 	CoursesByQuarter map[int][]*Course
 	CoursesBySubject map[string]map[string]*CourseBySubject
 	CoursesByKey     map[string][]*Course
+	// sub-sections like labs and study groups by the course key of their lecture
+	ComponentsByKey map[string][]*Course
+	PrereqGraph     *PrereqGraph
+	Keys            *KeyResolver
+	Instructors     *InstructorIndex
+	Search          *SearchIndex
+	Similar         *SimilarityIndex
 
 	Quarters []int
 	DataPath string
@@ -40,6 +51,7 @@ func NewCoursesStore(dataPath string) (*CoursesStore, error) {
 		CoursesByQuarter: make(map[int][]*Course),
 		CoursesBySubject: make(map[string]map[string]*CourseBySubject),
 		CoursesByKey:     make(map[string][]*Course),
+		ComponentsByKey:  make(map[string][]*Course),
 		DataPath:         dataPath,
 	}
 
@@ -64,6 +76,14 @@ func (cs *CoursesStore) LoadAllCourseFiles() error {
 		}
 
 		for _, course := range courses {
+			// files scraped before requisites were parsed
+			if course.Requisites == nil {
+				course.Requisites = ParseRequisites(course.Overview, course.Subject)
+			}
+			if course.Component == "" {
+				LinkComponent(course)
+			}
+
 			if course.Quarter > 0 {
 				cs.CoursesByQuarter[course.Quarter] = append(cs.CoursesByQuarter[course.Quarter], course)
 			}
@@ -114,6 +134,12 @@ func (cs *CoursesStore) LoadAllCourseFiles() error {
 	}
 
 	cs.UpdateQuartersList()
+	cs.LinkComponents()
+	cs.BuildPrereqGraph()
+	cs.BuildKeyResolver()
+	cs.BuildInstructorIndex()
+	cs.BuildSearchIndex()
+	cs.BuildSimilarityIndex()
 	return nil
 }
 
@@ -150,16 +176,22 @@ func (cs *CoursesStore) GetAvailableQuarters() []int {
 
 func GetCoursesByKeyHandler(store *CoursesStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		keyStr := r.URL.Query().Get("key")
-		if keyStr == "" {
-			http.Error(w, "Key parameter is required", http.StatusBadRequest)
+		res, ok := resolveKeyParam(store, w, r)
+		if !ok {
+			return
+		}
+
+		params, err := page.ParseParams(r.URL.Query(), page.Names(SECTION_SORTS), "-quarter")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		courses := store.GetCoursesByKey(keyStr)
+		courses := slices.Clone(store.GetCoursesByKey(res.Key))
+		page.Sort(courses, params, SECTION_SORTS)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(courses)
+		json.NewEncoder(w).Encode(page.Slice(courses, params))
 	}
 }
 
@@ -171,9 +203,17 @@ func GetCoursesBySubjectHandler(store *CoursesStore) http.HandlerFunc {
 			return
 		}
 
+		params, err := page.ParseParams(r.URL.Query(), page.Names(SUBJECT_SORTS), "number")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		courses := store.GetCoursesBySubject(subjectStr)
+		page.Sort(courses, params, SUBJECT_SORTS)
+
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(courses)
+		json.NewEncoder(w).Encode(page.Slice(courses, params))
 	}
 }
 
@@ -191,17 +231,43 @@ func GetCoursesByQuarterHandler(store *CoursesStore) http.HandlerFunc {
 			return
 		}
 
-		courses := store.GetCoursesByQuarter(quarter)
+		filter, err := ParseCourseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// without a sort sections keep the order they were scraped in
+		params, err := page.ParseParams(r.URL.Query(), page.Names(SECTION_SORTS), "")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		courses, facets := store.FilterCourses(store.GetCoursesByQuarter(quarter), filter)
+		page.Sort(courses, params, SECTION_SORTS)
+		res := CoursesResponse{Page: page.Slice(courses, params), Quarter: quarter, Facets: facets}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(courses)
+		json.NewEncoder(w).Encode(res)
 	}
 }
 
 func GetAvailableQuartersHandler(store *CoursesStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		quarters := store.GetAvailableQuarters()
+		params, err := page.ParseParams(r.URL.Query(), page.Names(QUARTER_SORTS), "-code")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		quarters := make([]term.Info, 0, len(store.Quarters))
+		for _, quarter := range store.GetAvailableQuarters() {
+			quarters = append(quarters, term.Of(quarter).Info())
+		}
+		page.Sort(quarters, params, QUARTER_SORTS)
+
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(quarters)
+		json.NewEncoder(w).Encode(page.Slice(quarters, params))
 	}
 }
@@ -0,0 +1,217 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	ELIGIBLE   = "eligible"
+	INELIGIBLE = "ineligible"
+	// the section can be taken with the permission of the instructor or department
+	NEEDS_CONSENT = "consent"
+)
+
+// Planned courses are ones the student will take in the same quarter, they only
+// count for prerequisites that may be taken concurrently and for corequisites
+type EligibilityRequest struct {
+	Quarter   int      `json:"quarter"`
+	Completed []string `json:"completed"`
+	Planned   []string `json:"planned"`
+}
+
+// Missing lists the courses still needed before the section can be taken,
+// Corequisites the ones that have to be taken alongside it
+type SectionEligibility struct {
+	Course       *Course  `json:"course"`
+	Missing      []string `json:"missing,omitempty"`
+	Corequisites []string `json:"corequisites,omitempty"`
+	Consent      string   `json:"consent,omitempty"`
+	// placement exams and the like that could not be checked
+	Unverified []string `json:"unverified,omitempty"`
+}
+
+type EligibilityReport struct {
+	Quarter    int                  `json:"quarter"`
+	Eligible   []SectionEligibility `json:"eligible"`
+	Ineligible []SectionEligibility `json:"ineligible"`
+	Consent    []SectionEligibility `json:"consent"`
+}
+
+func normalizeKeys(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.ToUpper(strings.TrimSpace(key)); key != "" {
+			normalized = append(normalized, key)
+		}
+	}
+	return normalized
+}
+
+func leafTexts(leaves []*PrereqExpr, kind int) []string {
+	texts := make([]string, 0)
+	for _, leaf := range leaves {
+		if leaf.Type != kind {
+			continue
+		}
+		text := leaf.Course
+		if kind != PREREQ_COURSE {
+			text = leaf.Text
+		}
+		if !slices.Contains(texts, text) {
+			texts = append(texts, text)
+		}
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+// the other leaves a satisfied expression relies on, or nodes prefer a child that
+// is met without any, assumed only differs from strict on other leaves
+func assumedLeaves(e *PrereqExpr, strict, assumed func(*PrereqExpr) bool) []string {
+	if e == nil || e.Satisfied(strict) {
+		return []string{}
+	}
+	switch e.Type {
+	case PREREQ_AND:
+		texts := make([]string, 0)
+		for _, child := range e.Children {
+			for _, text := range assumedLeaves(child, strict, assumed) {
+				if !slices.Contains(texts, text) {
+					texts = append(texts, text)
+				}
+			}
+		}
+		sort.Strings(texts)
+		return texts
+	case PREREQ_OR:
+		var best []string
+		for _, child := range e.Children {
+			if !child.Satisfied(assumed) {
+				continue
+			}
+			if texts := assumedLeaves(child, strict, assumed); best == nil || len(texts) < len(best) {
+				best = texts
+			}
+		}
+		return best
+	case PREREQ_OTHER:
+		return []string{e.Text}
+	}
+	return []string{}
+}
+
+// CheckEligibility sorts a section into eligible, ineligible or consent, consent
+// is only reported when the courses alone are not enough or the course always needs it
+func CheckEligibility(course *Course, completed, planned []string) (SectionEligibility, string) {
+	result := SectionEligibility{Course: course}
+	reqs := course.Requisites
+	if reqs == nil {
+		return result, ELIGIBLE
+	}
+
+	// other leaves can not be checked and are assumed to be met
+	taken := func(consent, other bool) func(*PrereqExpr) bool {
+		return func(leaf *PrereqExpr) bool {
+			switch leaf.Type {
+			case PREREQ_COURSE:
+				return slices.Contains(completed, leaf.Course) || leaf.Concurrent && slices.Contains(planned, leaf.Course)
+			case PREREQ_CONSENT:
+				return consent
+			}
+			return other
+		}
+	}
+	alongside := func(leaf *PrereqExpr) bool {
+		return leaf.Type != PREREQ_COURSE || slices.Contains(completed, leaf.Course) || slices.Contains(planned, leaf.Course)
+	}
+	result.Corequisites = leafTexts(reqs.Corequisites.Missing(alongside), PREREQ_COURSE)
+
+	// clauses the parser could not make sense of are passed on as they were written
+	if reqs.Prerequisites == nil && reqs.Corequisites == nil {
+		result.Unverified = reqs.Text
+		return result, ELIGIBLE
+	}
+
+	switch {
+	case reqs.Prerequisites.Satisfied(taken(false, true)):
+		result.Unverified = assumedLeaves(reqs.Prerequisites, taken(false, false), taken(false, true))
+		if reqs.Consent == CONSENT_REQUIRED {
+			result.Consent = CONSENT_REQUIRED
+			return result, NEEDS_CONSENT
+		}
+		return result, ELIGIBLE
+	case reqs.Prerequisites.Satisfied(taken(true, true)):
+		result.Unverified = assumedLeaves(reqs.Prerequisites, taken(true, false), taken(true, true))
+		result.Consent = CONSENT_ALTERNATIVE
+		return result, NEEDS_CONSENT
+	}
+
+	result.Missing = leafTexts(reqs.Prerequisites.Missing(taken(true, true)), PREREQ_COURSE)
+	return result, INELIGIBLE
+}
+
+// CheckQuarterEligibility skips courses the student already completed unless the
+// section has a topic, topics change between offerings and can be taken again
+func (cs *CoursesStore) CheckQuarterEligibility(quarter int, completed, planned []string) *EligibilityReport {
+	completed = normalizeKeys(completed)
+	planned = normalizeKeys(planned)
+
+	report := &EligibilityReport{
+		Quarter:    quarter,
+		Eligible:   []SectionEligibility{},
+		Ineligible: []SectionEligibility{},
+		Consent:    []SectionEligibility{},
+	}
+
+	for _, course := range cs.GetCoursesByQuarter(quarter) {
+		if course.Topic == "" && slices.Contains(completed, GetCourseKey(*course)) {
+			continue
+		}
+
+		result, status := CheckEligibility(course, completed, planned)
+		switch status {
+		case ELIGIBLE:
+			report.Eligible = append(report.Eligible, result)
+		case INELIGIBLE:
+			report.Ineligible = append(report.Ineligible, result)
+		default:
+			report.Consent = append(report.Consent, result)
+		}
+	}
+	return report
+}
+
+func EligibilityHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req EligibilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Quarter == 0 {
+			http.Error(w, "Quarter parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		if !slices.Contains(store.GetAvailableQuarters(), req.Quarter) {
+			http.Error(w, "Quarter not found", http.StatusNotFound)
+			return
+		}
+
+		completed, ambiguous := store.ResolveKeys(req.Completed)
+		planned, ambiguousPlanned := store.ResolveKeys(req.Planned)
+		if ambiguous = append(ambiguous, ambiguousPlanned...); len(ambiguous) > 0 {
+			WriteAmbiguousKeys(w, ambiguous)
+			return
+		}
+		req.Completed, req.Planned = completed, planned
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.CheckQuarterEligibility(req.Quarter, req.Completed, req.Planned))
+	}
+}
@@ -0,0 +1,293 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/page"
+)
+
+const (
+	FACET_SUBJECT = "subject"
+	FACET_SCHOOL  = "school"
+	FACET_DAY     = "day"
+)
+
+var DAY_NAMES = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// CourseFilter narrows the sections of a quarter, empty fields match everything. A
+// section passes Days when it meets on no other day and Start and End when every
+// meeting lies between them, sections with TBA meetings fail both
+type CourseFilter struct {
+	Subjects   []string
+	Schools    []string
+	MinLevel   int
+	MaxLevel   int
+	Days       []string
+	Start      int
+	End        int
+	Instructor string
+	TBA        *bool
+	Keywords   []string
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// every facet is counted with all filters but its own so the other values stay visible
+type CourseFacets struct {
+	Subjects []FacetCount `json:"subjects"`
+	Schools  []FacetCount `json:"schools"`
+	Days     []FacetCount `json:"days"`
+}
+
+// the page of sections with the facets of every section passing the filters
+type CoursesResponse struct {
+	page.Page[*Course]
+	Quarter int          `json:"quarter"`
+	Facets  CourseFacets `json:"facets"`
+}
+
+// list parameters can be repeated or comma separated
+func listParam(query url.Values, name string) []string {
+	values := make([]string, 0)
+	for _, param := range query[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func ParseCourseFilter(query url.Values) (CourseFilter, error) {
+	f := CourseFilter{Start: -1, End: -1}
+
+	// "cs" filters on COMP_SCI and "eecs" on the subjects it was split into
+	for _, subject := range listParam(query, "subject") {
+		subject = cleanSubject(subject)
+		if aliases, found := SUBJECT_ALIASES[strings.ReplaceAll(subject, "_", "")]; found {
+			f.Subjects = append(f.Subjects, aliases...)
+			continue
+		}
+		f.Subjects = append(f.Subjects, subject)
+	}
+	for _, school := range listParam(query, "school") {
+		f.Schools = append(f.Schools, strings.ToUpper(school))
+	}
+
+	for name, level := range map[string]*int{"minLevel": &f.MinLevel, "maxLevel": &f.MaxLevel} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return f, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*level = n
+		}
+	}
+	if f.MaxLevel > 0 && f.MaxLevel < f.MinLevel {
+		return f, fmt.Errorf("maxLevel is below minLevel")
+	}
+
+	for _, name := range listParam(query, "days") {
+		day := ParseDay(name)
+		i := slices.IndexFunc(DAY_NAMES, func(d string) bool { return strings.EqualFold(d, day) })
+		if i == -1 {
+			return f, fmt.Errorf("invalid day %q", name)
+		}
+		f.Days = append(f.Days, DAY_NAMES[i])
+	}
+
+	for name, bound := range map[string]*int{"start": &f.Start, "end": &f.End} {
+		if value := query.Get(name); value != "" {
+			minutes, err := ParseClock(value)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*bound = minutes
+		}
+	}
+
+	f.Instructor = strings.TrimSpace(query.Get("instructor"))
+
+	if value := query.Get("tba"); value != "" {
+		tba, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, fmt.Errorf("invalid tba %q", value)
+		}
+		f.TBA = &tba
+	}
+
+	for _, t := range tokenize(query.Get("q"), 0) {
+		f.Keywords = append(f.Keywords, t.text)
+	}
+	return f, nil
+}
+
+// a section is TBA when it has no meeting times or one of them has no time yet
+func HasTBA(course *Course) bool {
+	if len(course.MeetingTimes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(course.MeetingTimes, func(mt MeetingTime) bool {
+		return mt.StartTime.IsZero() && mt.EndTime.IsZero()
+	})
+}
+
+func courseLevel(course *Course) int {
+	base, _, _ := strings.Cut(course.Number, "-")
+	level, _ := strconv.Atoi(base)
+	return level
+}
+
+func (f CourseFilter) matchesDays(course *Course) bool {
+	if len(f.Days) == 0 {
+		return true
+	}
+	if HasTBA(course) {
+		return false
+	}
+	for _, mt := range course.MeetingTimes {
+		for _, day := range mt.Days {
+			if !slices.Contains(f.Days, day) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (f CourseFilter) matchesTimes(course *Course) bool {
+	if f.Start < 0 && f.End < 0 {
+		return true
+	}
+	if HasTBA(course) {
+		return false
+	}
+	for _, mt := range course.MeetingTimes {
+		start := mt.StartTime.Hour()*60 + mt.StartTime.Minute()
+		end := mt.EndTime.Hour()*60 + mt.EndTime.Minute()
+		if f.Start >= 0 && start < f.Start || f.End >= 0 && end > f.End {
+			return false
+		}
+	}
+	return true
+}
+
+// the instructor is an id from /api/instructors or part of a name
+func (cs *CoursesStore) matchesInstructor(f CourseFilter, course *Course) bool {
+	if f.Instructor == "" {
+		return true
+	}
+	name := NormalizeInstructorName(f.Instructor)
+	return slices.ContainsFunc(course.Instructors, func(instructor Instructor) bool {
+		if cs.Instructors != nil && cs.Instructors.InstructorID(instructor) == f.Instructor {
+			return true
+		}
+		return name != "" && strings.Contains(NormalizeInstructorName(instructor.Name), name)
+	})
+}
+
+// every keyword has to appear in the key, title, topic or overview
+func matchesKeywords(keywords []string, course *Course) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	words := make(map[string]bool)
+	for _, text := range []string{GetCourseKey(*course), course.Title, course.Topic, course.Overview} {
+		for _, t := range tokenize(text, 0) {
+			words[t.text] = true
+		}
+	}
+	for _, keyword := range keywords {
+		if !words[keyword] {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterCourses keeps the sections passing every filter and counts the facets
+func (cs *CoursesStore) FilterCourses(courses []*Course, f CourseFilter) ([]*Course, CourseFacets) {
+	type check struct {
+		facet string
+		pass  func(*Course) bool
+	}
+	checks := []check{
+		{FACET_SUBJECT, func(c *Course) bool { return len(f.Subjects) == 0 || slices.Contains(f.Subjects, c.Subject) }},
+		{FACET_SCHOOL, func(c *Course) bool { return len(f.Schools) == 0 || slices.Contains(f.Schools, c.School) }},
+		{FACET_DAY, f.matchesDays},
+		{"", func(c *Course) bool {
+			level := courseLevel(c)
+			return level >= f.MinLevel && (f.MaxLevel == 0 || level <= f.MaxLevel)
+		}},
+		{"", f.matchesTimes},
+		{"", func(c *Course) bool { return cs.matchesInstructor(f, c) }},
+		{"", func(c *Course) bool { return f.TBA == nil || HasTBA(c) == *f.TBA }},
+		{"", func(c *Course) bool { return matchesKeywords(f.Keywords, c) }},
+	}
+
+	subjects := make(map[string]int)
+	schools := make(map[string]int)
+	days := make(map[string]int)
+	filtered := make([]*Course, 0)
+	for _, course := range courses {
+		// the facet of the one failing check still counts the section
+		failed := ""
+		failures := 0
+		for _, c := range checks {
+			if !c.pass(course) {
+				failures++
+				failed = c.facet
+				if failures > 1 {
+					break
+				}
+			}
+		}
+
+		if failures == 0 {
+			filtered = append(filtered, course)
+		}
+		if failures == 0 || failures == 1 && failed == FACET_SUBJECT {
+			subjects[course.Subject]++
+		}
+		if failures == 0 || failures == 1 && failed == FACET_SCHOOL {
+			schools[course.School]++
+		}
+		if failures == 0 || failures == 1 && failed == FACET_DAY {
+			seen := make([]string, 0)
+			for _, mt := range course.MeetingTimes {
+				for _, day := range mt.Days {
+					if !slices.Contains(seen, day) {
+						seen = append(seen, day)
+						days[day]++
+					}
+				}
+			}
+		}
+	}
+
+	facets := CourseFacets{Subjects: facetCounts(subjects), Schools: facetCounts(schools), Days: make([]FacetCount, 0)}
+	for _, day := range DAY_NAMES {
+		if days[day] > 0 {
+			facets.Days = append(facets.Days, FacetCount{Value: day, Count: days[day]})
+		}
+	}
+	return filtered, facets
+}
+
+func facetCounts(counts map[string]int) []FacetCount {
+	facets := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool { return facets[i].Value < facets[j].Value })
+	return facets
+}
@@ -0,0 +1,349 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// InstructorIdentity is one person across every section and quarter, Names lists
+// the spellings the sections used
+type InstructorIdentity struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Names       []string `json:"names"`
+	Emails      []string `json:"emails"`
+	Phone       string   `json:"phone,omitempty"`
+	OfficeHours string   `json:"officeHours,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	Quarters    []int    `json:"quarters"`
+	Courses     []string `json:"courses"`
+	Sections    int      `json:"sections"`
+}
+
+// InstructorIndex gives the instructors of every section an identity, sections that
+// spell a name the same way or share an email belong to the same person
+type InstructorIndex struct {
+	Instructors map[string]*InstructorIdentity
+	Sections    map[string][]*Course
+	byName      map[string]string
+	byEmail     map[string]string
+}
+
+type InstructorRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type InstructorScheduleResponse struct {
+	Instructor *InstructorIdentity `json:"instructor"`
+	Quarter    int                 `json:"quarter"`
+	Sections   []*Course           `json:"sections"`
+}
+
+type QuarterTeaching struct {
+	Quarter  int   `json:"quarter"`
+	Sections []int `json:"sections"`
+}
+
+// Sections counts every section taught across the quarters
+type CourseTeaching struct {
+	Instructor InstructorRef     `json:"instructor"`
+	Quarters   []QuarterTeaching `json:"quarters"`
+	Sections   int               `json:"sections"`
+}
+
+type CourseInstructorsResponse struct {
+	Course      string           `json:"course"`
+	Instructors []CourseTeaching `json:"instructors"`
+}
+
+// "Erik J. Friedman" and "erik j friedman" are the same name
+func NormalizeInstructorName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	})
+	return strings.Join(fields, " ")
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ids are the normalized name as a slug so they stay the same across reloads
+func instructorID(normalized string) string {
+	return strings.NewReplacer(" ", "-", "'", "").Replace(normalized)
+}
+
+func (cs *CoursesStore) BuildInstructorIndex() {
+	idx := &InstructorIndex{
+		Instructors: make(map[string]*InstructorIdentity),
+		Sections:    make(map[string][]*Course),
+		byName:      make(map[string]string),
+		byEmail:     make(map[string]string),
+	}
+
+	// union find over normalized names, an email seen with two names joins them
+	parent := make(map[string]string)
+	var find func(name string) string
+	find = func(name string) string {
+		if parent[name] != name {
+			parent[name] = find(parent[name])
+		}
+		return parent[name]
+	}
+	emails := make(map[string]string)
+	spellings := make(map[string]map[string]int)
+
+	quarters := append([]int{}, cs.Quarters...)
+	sort.Ints(quarters)
+	for _, quarter := range quarters {
+		for _, course := range cs.CoursesByQuarter[quarter] {
+			for _, instructor := range course.Instructors {
+				name := NormalizeInstructorName(instructor.Name)
+				if name == "" {
+					continue
+				}
+				if _, found := parent[name]; !found {
+					parent[name] = name
+					spellings[name] = make(map[string]int)
+				}
+				spellings[name][strings.TrimSpace(instructor.Name)]++
+
+				if email := normalizeEmail(instructor.Email); email != "" {
+					if other, found := emails[email]; found {
+						if a, b := find(name), find(other); a != b {
+							parent[max(a, b)] = min(a, b)
+						}
+					} else {
+						emails[email] = name
+					}
+				}
+			}
+		}
+	}
+
+	// the spelling used on the most sections names the person and gives their id
+	best := make(map[string]string)
+	count := make(map[string]int)
+	for name, seen := range spellings {
+		root := find(name)
+		for spelling, n := range seen {
+			if n > count[root] || n == count[root] && spelling < best[root] {
+				best[root], count[root] = spelling, n
+			}
+		}
+	}
+	names := make(map[string]string)
+	for name := range spellings {
+		root := find(name)
+		idx.byName[name] = instructorID(NormalizeInstructorName(best[root]))
+		names[idx.byName[name]] = best[root]
+	}
+	for email, name := range emails {
+		idx.byEmail[email] = idx.byName[name]
+	}
+
+	for _, quarter := range quarters {
+		for _, course := range cs.CoursesByQuarter[quarter] {
+			for _, instructor := range course.Instructors {
+				id := idx.InstructorID(instructor)
+				if id == "" {
+					continue
+				}
+
+				identity, found := idx.Instructors[id]
+				if !found {
+					identity = &InstructorIdentity{ID: id, Name: names[id], Names: []string{}, Emails: []string{}, Quarters: []int{}, Courses: []string{}}
+					idx.Instructors[id] = identity
+				}
+
+				// later quarters overwrite contact details
+				if name := strings.TrimSpace(instructor.Name); !slices.Contains(identity.Names, name) {
+					identity.Names = append(identity.Names, name)
+				}
+				if email := normalizeEmail(instructor.Email); email != "" && !slices.Contains(identity.Emails, email) {
+					identity.Emails = append(identity.Emails, email)
+				}
+				if instructor.Phone != "" {
+					identity.Phone = instructor.Phone
+				}
+				if instructor.OfficeHours != "" {
+					identity.OfficeHours = instructor.OfficeHours
+				}
+				if instructor.Address != "" {
+					identity.Address = instructor.Address
+				}
+				if !slices.Contains(identity.Quarters, quarter) {
+					identity.Quarters = append(identity.Quarters, quarter)
+				}
+				if key := GetCourseKey(*course); !slices.Contains(identity.Courses, key) {
+					identity.Courses = append(identity.Courses, key)
+				}
+				if !slices.Contains(idx.Sections[id], course) {
+					idx.Sections[id] = append(idx.Sections[id], course)
+					identity.Sections++
+				}
+			}
+		}
+	}
+
+	for _, identity := range idx.Instructors {
+		sort.Strings(identity.Courses)
+	}
+
+	cs.Instructors = idx
+}
+
+// InstructorID finds the identity of an instructor listed on a section, the email
+// is trusted over the name
+func (idx *InstructorIndex) InstructorID(instructor Instructor) string {
+	if id, found := idx.byEmail[normalizeEmail(instructor.Email)]; found {
+		return id
+	}
+	return idx.byName[NormalizeInstructorName(instructor.Name)]
+}
+
+func (idx *InstructorIndex) Ref(id string) InstructorRef {
+	return InstructorRef{ID: id, Name: idx.Instructors[id].Name}
+}
+
+// the sections an instructor teaches in a quarter
+func (idx *InstructorIndex) Schedule(id string, quarter int) []*Course {
+	sections := make([]*Course, 0)
+	for _, course := range idx.Sections[id] {
+		if course.Quarter == quarter {
+			sections = append(sections, course)
+		}
+	}
+	return sections
+}
+
+// CourseHistory lists who taught a course in which quarters, the most frequent first
+func (cs *CoursesStore) CourseHistory(key string) []CourseTeaching {
+	byID := make(map[string]*CourseTeaching)
+	order := make([]string, 0)
+	for _, course := range cs.CoursesByKey[key] {
+		for _, instructor := range course.Instructors {
+			id := cs.Instructors.InstructorID(instructor)
+			if id == "" {
+				continue
+			}
+			teaching, found := byID[id]
+			if !found {
+				teaching = &CourseTeaching{Instructor: cs.Instructors.Ref(id), Quarters: []QuarterTeaching{}}
+				byID[id] = teaching
+				order = append(order, id)
+			}
+
+			i := slices.IndexFunc(teaching.Quarters, func(q QuarterTeaching) bool { return q.Quarter == course.Quarter })
+			if i == -1 {
+				teaching.Quarters = append(teaching.Quarters, QuarterTeaching{Quarter: course.Quarter, Sections: []int{}})
+				i = len(teaching.Quarters) - 1
+			}
+			if !slices.Contains(teaching.Quarters[i].Sections, course.Section) {
+				teaching.Quarters[i].Sections = append(teaching.Quarters[i].Sections, course.Section)
+				teaching.Sections++
+			}
+		}
+	}
+
+	history := make([]CourseTeaching, 0, len(order))
+	for _, id := range order {
+		teaching := byID[id]
+		sort.Slice(teaching.Quarters, func(i, j int) bool { return teaching.Quarters[i].Quarter > teaching.Quarters[j].Quarter })
+		history = append(history, *teaching)
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		if history[i].Sections != history[j].Sections {
+			return history[i].Sections > history[j].Sections
+		}
+		return history[i].Instructor.Name < history[j].Instructor.Name
+	})
+	return history
+}
+
+// quarter and q narrow the list to instructors teaching in that quarter and whose name contains q
+func GetInstructorsHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quarter := 0
+		if quarterStr := r.URL.Query().Get("quarter"); quarterStr != "" {
+			var err error
+			if quarter, err = strconv.Atoi(quarterStr); err != nil {
+				http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+				return
+			}
+		}
+		q := NormalizeInstructorName(r.URL.Query().Get("q"))
+
+		instructors := make([]*InstructorIdentity, 0, len(store.Instructors.Instructors))
+		for _, identity := range store.Instructors.Instructors {
+			if quarter != 0 && !slices.Contains(identity.Quarters, quarter) {
+				continue
+			}
+			if q != "" && !slices.ContainsFunc(identity.Names, func(name string) bool {
+				return strings.Contains(NormalizeInstructorName(name), q)
+			}) {
+				continue
+			}
+			instructors = append(instructors, identity)
+		}
+		sort.Slice(instructors, func(i, j int) bool { return instructors[i].Name < instructors[j].Name })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(instructors)
+	}
+}
+
+// without a quarter the latest quarter the instructor taught in is used
+func GetInstructorScheduleHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, found := store.Instructors.Instructors[r.PathValue("id")]
+		if !found {
+			http.Error(w, "Instructor not found", http.StatusNotFound)
+			return
+		}
+
+		quarter := slices.Max(identity.Quarters)
+		if quarterStr := r.URL.Query().Get("quarter"); quarterStr != "" {
+			var err error
+			if quarter, err = strconv.Atoi(quarterStr); err != nil {
+				http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		res := InstructorScheduleResponse{
+			Instructor: identity,
+			Quarter:    quarter,
+			Sections:   store.Instructors.Schedule(identity.ID, quarter),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+func GetCourseInstructorsHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resolved, ok := resolveKeyParam(store, w, r)
+		if !ok {
+			return
+		}
+
+		key := resolved.Key
+		if _, found := store.CoursesByKey[key]; !found {
+			http.Error(w, "Course not found", http.StatusNotFound)
+			return
+		}
+
+		res := CourseInstructorsResponse{Course: key, Instructors: store.CourseHistory(key)}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
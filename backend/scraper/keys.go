@@ -0,0 +1,368 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// how a candidate key was reached from the input, lower is a closer match
+const (
+	MATCH_EXACT = iota
+	MATCH_ALIAS
+	MATCH_FUZZY
+)
+
+var MATCH_NAMES = []string{"exact", "alias", "fuzzy"}
+
+// short names students and overviews use for subjects, EECS was split into three
+// subjects that kept the old course numbers
+var SUBJECT_ALIASES = map[string][]string{
+	"CS":         {"COMP_SCI"},
+	"COMPSCI":    {"COMP_SCI"},
+	"EECS":       {"COMP_SCI", "COMP_ENG", "ELEC_ENG"},
+	"CE":         {"COMP_ENG"},
+	"CPE":        {"COMP_ENG"},
+	"EE":         {"ELEC_ENG"},
+	"ECE":        {"ELEC_ENG", "COMP_ENG"},
+	"CEE":        {"CIV_ENV"},
+	"CIVIL":      {"CIV_ENV"},
+	"ME":         {"MECH_ENG"},
+	"MECH":       {"MECH_ENG"},
+	"BME":        {"BMD_ENG"},
+	"CHE":        {"CHEM_ENG"},
+	"CHEME":      {"CHEM_ENG"},
+	"MSE":        {"MAT_SCI"},
+	"ESAM":       {"ES_APPM"},
+	"APPM":       {"ES_APPM"},
+	"DTC":        {"DSGN"},
+	"DESIGN":     {"DSGN"},
+	"BIO":        {"BIOL_SCI"},
+	"BIOLOGY":    {"BIOL_SCI"},
+	"PHYS":       {"PHYSICS"},
+	"SPAN":       {"SPANISH"},
+	"STATS":      {"STAT"},
+	"PSYC":       {"PSYCH"},
+	"POLSCI":     {"POLI_SCI"},
+	"ECONOMICS":  {"ECON"},
+	"PHILOSOPHY": {"PHIL"},
+}
+
+// subject, three digit number and an optional sequence like "-1", "1" or "-SG"
+var keyInput = regexp.MustCompile(`^([A-Z][A-Z_&.\s-]*?)[\s_-]*(\d{3})(?:\s*[-\s]\s*([0-9A-Z]+))?$`)
+
+type KeyCandidate struct {
+	Key     string `json:"key"`
+	Title   string `json:"title,omitempty"`
+	Offered bool   `json:"offered"`
+	Match   string `json:"match"`
+	rank    int
+}
+
+// KeyResolution is empty when the input names no course, Key is empty and Ambiguous
+// set when several candidates are equally close, Guess is the canonical form of an
+// input that names no known course
+type KeyResolution struct {
+	Input      string         `json:"input"`
+	Key        string         `json:"key"`
+	Guess      string         `json:"guess,omitempty"`
+	Ambiguous  bool           `json:"ambiguous"`
+	Candidates []KeyCandidate `json:"candidates"`
+}
+
+// KeyResolver turns what students type into canonical course keys, it knows every
+// offered course and every course named in a prerequisite
+type KeyResolver struct {
+	subjects []string
+	squashed map[string]string
+	keys     map[string]bool
+	byBase   map[string][]string
+	titles   map[string]string
+}
+
+func (cs *CoursesStore) BuildKeyResolver() {
+	kr := &KeyResolver{
+		squashed: make(map[string]string),
+		keys:     make(map[string]bool),
+		byBase:   make(map[string][]string),
+		titles:   make(map[string]string),
+	}
+
+	add := func(key string) {
+		if kr.keys[key] {
+			return
+		}
+		subject, number, found := strings.Cut(key, " ")
+		if !found {
+			return
+		}
+		kr.keys[key] = true
+		base, _, _ := strings.Cut(number, "-")
+		kr.byBase[subject+" "+base] = append(kr.byBase[subject+" "+base], key)
+		if !slices.Contains(kr.subjects, subject) {
+			kr.subjects = append(kr.subjects, subject)
+			kr.squashed[strings.ReplaceAll(subject, "_", "")] = subject
+		}
+	}
+
+	for key, sections := range cs.CoursesByKey {
+		add(key)
+		kr.titles[key] = sections[len(sections)-1].Title
+	}
+	if cs.PrereqGraph != nil {
+		for key := range cs.PrereqGraph.Unlocks {
+			add(key)
+		}
+	}
+	sort.Strings(kr.subjects)
+	for _, keys := range kr.byBase {
+		sort.Strings(keys)
+	}
+
+	cs.Keys = kr
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+type subjectMatch struct {
+	subject string
+	match   int
+	rank    int
+}
+
+// cleanSubject turns "comp sci" or "Comp-Sci." into "COMP_SCI"
+func cleanSubject(subject string) string {
+	fields := strings.FieldsFunc(strings.ToUpper(subject), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(fields, "_")
+}
+
+// an old code like EECS can still be a known subject through prerequisites that name
+// it, so its aliases are tried as well
+func (kr *KeyResolver) matchSubjects(subject string) []subjectMatch {
+	matches := make([]subjectMatch, 0)
+	if slices.Contains(kr.subjects, subject) {
+		matches = append(matches, subjectMatch{subject, MATCH_EXACT, 0})
+	}
+
+	squashed := strings.ReplaceAll(subject, "_", "")
+	for i, alias := range SUBJECT_ALIASES[squashed] {
+		matches = append(matches, subjectMatch{alias, MATCH_ALIAS, i})
+	}
+	if known, found := kr.squashed[squashed]; found && known != subject {
+		matches = append(matches, subjectMatch{known, MATCH_ALIAS, 0})
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	// typos, a short subject only tolerates one
+	allowed := 1
+	if len(squashed) >= 6 {
+		allowed = 2
+	}
+	for short, known := range kr.squashed {
+		if d := levenshtein(squashed, short); d <= allowed && len(squashed) >= 3 {
+			matches = append(matches, subjectMatch{known, MATCH_FUZZY, d})
+		}
+	}
+	return matches
+}
+
+// sequences are ranked "-0" first, then numbered parts, then sub-sections like "-SG"
+func sequenceRank(key string) int {
+	_, sequence, _ := strings.Cut(key[strings.LastIndex(key, " ")+1:], "-")
+	switch {
+	case sequence == "0":
+		return 0
+	case sequence != "" && sequence[0] >= '0' && sequence[0] <= '9':
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Resolve ranks the known keys the input could mean, by how the subject matched, then
+// offered courses over ones only named in prerequisites, then by how likely the
+// sequence is when the input left it out
+func (kr *KeyResolver) Resolve(input string) KeyResolution {
+	res := KeyResolution{Input: input, Candidates: []KeyCandidate{}}
+	raw := strings.Join(strings.Fields(strings.ToUpper(input)), " ")
+	if kr.keys[raw] {
+		res.Key = raw
+		res.Candidates = append(res.Candidates, kr.candidate(raw, MATCH_EXACT, 0))
+		return res
+	}
+
+	m := keyInput.FindStringSubmatch(raw)
+	if m == nil {
+		return res
+	}
+	subject, number, sequence := cleanSubject(m[1]), m[2], m[3]
+
+	matches := kr.matchSubjects(subject)
+	for _, sm := range matches {
+		base := sm.subject + " " + number
+		keys := kr.byBase[base]
+		if sequence != "" {
+			keys = slices.DeleteFunc(slices.Clone(keys), func(key string) bool { return key != base+"-"+sequence })
+		}
+		for _, key := range keys {
+			rank := sm.rank*10 + sequenceRank(key)
+			if sequence != "" {
+				rank = sm.rank * 10
+			}
+			if !slices.ContainsFunc(res.Candidates, func(c KeyCandidate) bool { return c.Key == key }) {
+				res.Candidates = append(res.Candidates, kr.candidate(key, sm.match, rank))
+			}
+		}
+	}
+
+	sort.SliceStable(res.Candidates, func(i, j int) bool {
+		a, b := res.Candidates[i], res.Candidates[j]
+		if a.Match != b.Match {
+			return slices.Index(MATCH_NAMES, a.Match) < slices.Index(MATCH_NAMES, b.Match)
+		}
+		if a.Offered != b.Offered {
+			return a.Offered
+		}
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		return a.Key < b.Key
+	})
+
+	if len(res.Candidates) == 0 {
+		guess := subject
+		if len(matches) > 0 {
+			guess = matches[0].subject
+		}
+		if sequence == "" {
+			sequence = "0"
+		}
+		res.Guess = guess + " " + number + "-" + sequence
+		return res
+	}
+
+	best := res.Candidates[0]
+	if len(res.Candidates) > 1 {
+		next := res.Candidates[1]
+		res.Ambiguous = next.Match == best.Match && next.rank == best.rank && next.Offered == best.Offered
+	}
+	if !res.Ambiguous {
+		res.Key = best.Key
+	}
+	return res
+}
+
+func (kr *KeyResolver) candidate(key string, match int, rank int) KeyCandidate {
+	title, offered := kr.titles[key]
+	return KeyCandidate{Key: key, Title: title, Offered: offered, Match: MATCH_NAMES[match], rank: rank}
+}
+
+func (cs *CoursesStore) ResolveKey(input string) KeyResolution {
+	return cs.Keys.Resolve(input)
+}
+
+// ResolveKeys maps a list of inputs to canonical keys, inputs that name no known course
+// keep their canonical guess or are passed through and ambiguous ones are returned
+func (cs *CoursesStore) ResolveKeys(inputs []string) ([]string, []KeyResolution) {
+	keys := make([]string, 0, len(inputs))
+	ambiguous := make([]KeyResolution, 0)
+	for _, input := range inputs {
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+		res := cs.Keys.Resolve(input)
+		switch {
+		case res.Ambiguous:
+			ambiguous = append(ambiguous, res)
+		case res.Key != "":
+			keys = append(keys, res.Key)
+		case res.Guess != "":
+			keys = append(keys, res.Guess)
+		default:
+			keys = append(keys, strings.ToUpper(strings.TrimSpace(input)))
+		}
+	}
+	return keys, ambiguous
+}
+
+// NormalizeKey formats a key without knowing which courses exist, for callers that have
+// no store, only subjects with a single alias are rewritten
+func NormalizeKey(input string) string {
+	raw := strings.Join(strings.Fields(strings.ToUpper(input)), " ")
+	m := keyInput.FindStringSubmatch(raw)
+	if m == nil {
+		return raw
+	}
+	subject := cleanSubject(m[1])
+	if aliases := SUBJECT_ALIASES[strings.ReplaceAll(subject, "_", "")]; len(aliases) == 1 {
+		subject = aliases[0]
+	}
+	key := subject + " " + m[2]
+	if m[3] != "" {
+		key += "-" + m[3]
+	}
+	return key
+}
+
+// WriteAmbiguousKeys answers with the candidates of every ambiguous input so the client
+// can ask which course was meant
+func WriteAmbiguousKeys(w http.ResponseWriter, ambiguous []KeyResolution) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string][]KeyResolution{"ambiguous": ambiguous})
+}
+
+// resolveKeyParam reads the key query parameter, writing the response itself when the
+// key is missing or ambiguous
+func resolveKeyParam(store *CoursesStore, w http.ResponseWriter, r *http.Request) (KeyResolution, bool) {
+	input := strings.TrimSpace(r.URL.Query().Get("key"))
+	if input == "" {
+		http.Error(w, "Key parameter is required", http.StatusBadRequest)
+		return KeyResolution{}, false
+	}
+
+	res := store.ResolveKey(input)
+	if res.Ambiguous {
+		WriteAmbiguousKeys(w, []KeyResolution{res})
+		return res, false
+	}
+	return res, true
+}
+
+func ResolveKeyHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := strings.TrimSpace(r.URL.Query().Get("key"))
+		if input == "" {
+			http.Error(w, "Key parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.ResolveKey(input))
+	}
+}
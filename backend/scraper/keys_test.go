@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	offered := []string{
+		"MATH 220-1", "MATH 220-2", "COMP_SCI 211-0", "COMP_SCI 111-0", "COMP_ENG 203-0",
+		"ELEC_ENG 203-0", "PHYSICS 135-2", "PHYSICS 135-3", "PHYSICS 135-SG",
+	}
+	store := &CoursesStore{
+		CoursesByKey: make(map[string][]*Course),
+		// only named in prerequisites
+		PrereqGraph: &PrereqGraph{Unlocks: map[string][]string{
+			"MATH 220-0":     {"MATH 230-1"},
+			"EECS 211-0":     {"COMP_SCI 213-0"},
+			"COMP_SCI 150-0": {"COMP_SCI 211-0"},
+		}},
+	}
+	for _, key := range offered {
+		subject, number, _ := strings.Cut(key, " ")
+		store.CoursesByKey[key] = []*Course{{Subject: subject, Number: number, Title: key}}
+	}
+	store.BuildKeyResolver()
+
+	tests := []struct {
+		input      string
+		key        string
+		ambiguous  bool
+		candidates []string
+		guess      string
+	}{
+		{input: "math 220", ambiguous: true, candidates: []string{"MATH 220-1", "MATH 220-2", "MATH 220-0"}},
+		{input: "MATH 220-0", key: "MATH 220-0", candidates: []string{"MATH 220-0"}},
+		{input: "math 220 2", key: "MATH 220-2", candidates: []string{"MATH 220-2"}},
+		{input: "cs 211", key: "COMP_SCI 211-0", candidates: []string{"COMP_SCI 211-0"}},
+		{input: "COMP-SCI 211", key: "COMP_SCI 211-0", candidates: []string{"COMP_SCI 211-0"}},
+		{input: "comp sci 150", key: "COMP_SCI 150-0", candidates: []string{"COMP_SCI 150-0"}},
+		{input: "eecs 211", key: "EECS 211-0", candidates: []string{"EECS 211-0", "COMP_SCI 211-0"}},
+		{input: "eecs 203", key: "COMP_ENG 203-0", candidates: []string{"COMP_ENG 203-0", "ELEC_ENG 203-0"}},
+		{input: "physics 135", ambiguous: true, candidates: []string{"PHYSICS 135-2", "PHYSICS 135-3", "PHYSICS 135-SG"}},
+		{input: "phyiscs 135-3", key: "PHYSICS 135-3", candidates: []string{"PHYSICS 135-3"}},
+		{input: "cs 999", guess: "COMP_SCI 999-0"},
+		{input: "intro to programming"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			res := store.ResolveKey(tt.input)
+			if res.Key != tt.key || res.Ambiguous != tt.ambiguous || res.Guess != tt.guess {
+				t.Errorf("got key %q ambiguous %v guess %q, want %q %v %q", res.Key, res.Ambiguous, res.Guess, tt.key, tt.ambiguous, tt.guess)
+			}
+			keys := make([]string, 0, len(res.Candidates))
+			for _, c := range res.Candidates {
+				keys = append(keys, c.Key)
+			}
+			if tt.candidates == nil {
+				tt.candidates = []string{}
+			}
+			if !reflect.DeepEqual(keys, tt.candidates) {
+				t.Errorf("candidates = %q, want %q", keys, tt.candidates)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// a block present in both catalog years whose contents changed, generic blocks
+// report the options that were added or dropped and count blocks the new count
+type BlockChange struct {
+	Name            string   `json:"name,omitempty"`
+	PreviousName    string   `json:"previousName,omitempty"`
+	Type            int      `json:"type"`
+	AddedOptions    []Option `json:"addedOptions,omitempty"`
+	RemovedOptions  []Option `json:"removedOptions,omitempty"`
+	NumRequirements *[2]int  `json:"numRequirements,omitempty"`
+}
+
+type RequirementsDiff struct {
+	Major    string `json:"major"`
+	FromYear int    `json:"fromYear"`
+	ToYear   int    `json:"toYear"`
+	// set when the major moved in or out of the engineering school
+	IsEngineering *[2]bool      `json:"isEngineering,omitempty"`
+	Added         []Req         `json:"added"`
+	Removed       []Req         `json:"removed"`
+	Changed       []BlockChange `json:"changed"`
+	Unchanged     int           `json:"unchanged"`
+}
+
+func numRequirements(req Req) int {
+	switch r := req.(type) {
+	case ThemeRequirements:
+		return r.NumRequirements
+	case UnrestrictedRequirements:
+		return r.NumRequirements
+	case UnknownRequirements:
+		return r.NumRequirements
+	}
+	return 0
+}
+
+// options are compared ignoring their order, an option listed twice has to be
+// listed twice in the other year as well
+func diffOptions(from, to []Option) (added, removed []Option) {
+	remaining := make(map[string]int)
+	for _, option := range from {
+		remaining[optionSignature(option)]++
+	}
+	for _, option := range to {
+		sig := optionSignature(option)
+		if remaining[sig] > 0 {
+			remaining[sig]--
+			continue
+		}
+		added = append(added, option)
+	}
+
+	unmatched := make(map[string]int)
+	for _, option := range to {
+		unmatched[optionSignature(option)]++
+	}
+	for _, option := range from {
+		sig := optionSignature(option)
+		if unmatched[sig] > 0 {
+			unmatched[sig]--
+			continue
+		}
+		removed = append(removed, option)
+	}
+	return added, removed
+}
+
+func diffBlock(from, to Req) (BlockChange, bool) {
+	change := BlockChange{Type: to.GetType()}
+
+	fromGeneric, ok := from.(GenericRequirements)
+	if !ok {
+		if numRequirements(from) == numRequirements(to) {
+			return change, false
+		}
+		change.NumRequirements = &[2]int{numRequirements(from), numRequirements(to)}
+		return change, true
+	}
+
+	toGeneric := to.(GenericRequirements)
+	change.Name = toGeneric.Name
+	if fromGeneric.Name != toGeneric.Name {
+		change.PreviousName = fromGeneric.Name
+	}
+	change.AddedOptions, change.RemovedOptions = diffOptions(fromGeneric.Requirements, toGeneric.Requirements)
+
+	changed := change.PreviousName != "" || len(change.AddedOptions) > 0 || len(change.RemovedOptions) > 0
+	return change, changed
+}
+
+// DiffMajorRequirements pairs up the blocks of two catalog years the same way the
+// core is merged into a major, by kind and normalized name, and reports what changed
+func DiffMajorRequirements(from, to *MajorRequirements) *RequirementsDiff {
+	diff := &RequirementsDiff{
+		Major:    to.Major,
+		FromYear: from.CatalogYear,
+		ToYear:   to.CatalogYear,
+		Added:    []Req{},
+		Removed:  []Req{},
+		Changed:  []BlockChange{},
+	}
+	if from.IsEngineering != to.IsEngineering {
+		diff.IsEngineering = &[2]bool{from.IsEngineering, to.IsEngineering}
+	}
+
+	// blocks sharing a key are paired in the order they appear
+	previous := make(map[string][]Req)
+	for _, req := range from.AllRequirements {
+		previous[blockKey(req)] = append(previous[blockKey(req)], req)
+	}
+
+	for _, req := range to.AllRequirements {
+		key := blockKey(req)
+		if len(previous[key]) == 0 {
+			diff.Added = append(diff.Added, req)
+			continue
+		}
+
+		old := previous[key][0]
+		previous[key] = previous[key][1:]
+		if change, changed := diffBlock(old, req); changed {
+			diff.Changed = append(diff.Changed, change)
+		} else {
+			diff.Unchanged++
+		}
+	}
+
+	// whatever was not paired is gone, the leftovers are the last blocks of each key
+	for _, req := range from.AllRequirements {
+		key := blockKey(req)
+		if len(previous[key]) > 0 {
+			diff.Removed = append(diff.Removed, previous[key]...)
+			delete(previous, key)
+		}
+	}
+
+	return diff
+}
+
+// from is a required catalog year, to defaults to the most recent catalog year of the
+// major and has to be a different year
+func GetRequirementsDiffHandler(store *MajorRequirementsStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		major := r.URL.Query().Get("major")
+		if major == "" {
+			http.Error(w, "Major parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		if r.URL.Query().Get("from") == "" {
+			http.Error(w, "From parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		fromYear, err := parseYear(r, "from")
+		if err != nil || fromYear <= 0 {
+			http.Error(w, "Invalid from format", http.StatusBadRequest)
+			return
+		}
+
+		toYear, err := parseYear(r, "to")
+		if err != nil {
+			http.Error(w, "Invalid to format", http.StatusBadRequest)
+			return
+		}
+
+		from, found := store.GetRequirementsForYear(major, fromYear)
+		if !found {
+			http.Error(w, "Major not found for the from year", http.StatusNotFound)
+			return
+		}
+
+		to, found := store.GetRequirementsForYear(major, toYear)
+		if !found {
+			http.Error(w, "Major not found for the to year", http.StatusNotFound)
+			return
+		}
+
+		if from.CatalogYear == to.CatalogYear {
+			http.Error(w, "From and to are the same catalog year", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DiffMajorRequirements(from, to))
+	}
+}
@@ -0,0 +1,177 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// wire format of a requirement block, the files on disk use "type" while older
+// data and the frontend use "requirementType" so both spellings are accepted
+type reqJSON struct {
+	Type            *int     `json:"type,omitempty"`
+	RequirementType *int     `json:"requirementType,omitempty"`
+	Name            string   `json:"name,omitempty"`
+	Requirements    []Option `json:"requirements,omitempty"`
+	NumReqs         *int     `json:"numreqs,omitempty"`
+	NumRequirements *int     `json:"numRequirements,omitempty"`
+}
+
+func (rj reqJSON) kind() (int, bool) {
+	if rj.Type != nil {
+		return *rj.Type, true
+	}
+	if rj.RequirementType != nil {
+		return *rj.RequirementType, true
+	}
+	return 0, false
+}
+
+func (rj reqJSON) numreqs() int {
+	if rj.NumReqs != nil {
+		return *rj.NumReqs
+	}
+	if rj.NumRequirements != nil {
+		return *rj.NumRequirements
+	}
+	return 0
+}
+
+// both spellings are written so existing clients keep working
+func newReqJSON(kind int) reqJSON {
+	return reqJSON{Type: &kind, RequirementType: &kind}
+}
+
+// blocks without a recognized type wrap ErrUnknownType, anything else is bad json
+var ErrUnknownType = errors.New("unknown requirement type")
+
+func UnmarshalReq(data []byte) (Req, error) {
+	var rj reqJSON
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return nil, fmt.Errorf("error unmarshaling requirement block: %w", err)
+	}
+
+	kind, ok := rj.kind()
+	if !ok {
+		// blocks without a discriminator are generic when they list options
+		if rj.Name == "" && rj.Requirements == nil {
+			return nil, fmt.Errorf("%w, block has none: %s", ErrUnknownType, data)
+		}
+		kind = GENERIC_REQUIREMENTS
+	}
+
+	switch kind {
+	case GENERIC_REQUIREMENTS:
+		return GenericRequirements{Name: rj.Name, Requirements: rj.Requirements}, nil
+	case THEME_REQUIREMENTS:
+		return ThemeRequirements{NumRequirements: rj.numreqs()}, nil
+	case UNRESTRICTED_REQUIREMENTS:
+		return UnrestrictedRequirements{NumRequirements: rj.numreqs()}, nil
+	case UNKNOWN_REQUIREMENTS:
+		return UnknownRequirements{NumRequirements: rj.numreqs()}, nil
+	default:
+		return nil, fmt.Errorf("%w %d", ErrUnknownType, kind)
+	}
+}
+
+func UnmarshalRequirements(data []byte) ([]Req, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, fmt.Errorf("error unmarshaling requirements: %w", err)
+	}
+
+	reqs := make([]Req, 0, len(raws))
+	for _, raw := range raws {
+		req, err := UnmarshalReq(raw)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+func (mr *MajorRequirements) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		IsEngineering   bool            `json:"isEngineering"`
+		Major           string          `json:"major"`
+		CatalogYear     int             `json:"catalogYear"`
+		AllRequirements json.RawMessage `json:"allreqs"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	mr.IsEngineering = aux.IsEngineering
+	mr.Major = aux.Major
+	mr.CatalogYear = aux.CatalogYear
+	mr.AllRequirements = nil
+	if len(aux.AllRequirements) == 0 || string(aux.AllRequirements) == "null" {
+		return nil
+	}
+
+	reqs, err := UnmarshalRequirements(aux.AllRequirements)
+	if err != nil {
+		return fmt.Errorf("major %s: %w", aux.Major, err)
+	}
+	mr.AllRequirements = reqs
+	return nil
+}
+
+func (gr GenericRequirements) MarshalJSON() ([]byte, error) {
+	rj := newReqJSON(GENERIC_REQUIREMENTS)
+	rj.Name = gr.Name
+	rj.Requirements = gr.Requirements
+	return json.Marshal(rj)
+}
+
+func (gr *GenericRequirements) UnmarshalJSON(data []byte) error {
+	var rj reqJSON
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return err
+	}
+	gr.Name = rj.Name
+	gr.Requirements = rj.Requirements
+	return nil
+}
+
+func marshalCount(kind, numreqs int) ([]byte, error) {
+	rj := newReqJSON(kind)
+	rj.NumReqs = &numreqs
+	return json.Marshal(rj)
+}
+
+func unmarshalCount(data []byte) (int, error) {
+	var rj reqJSON
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return 0, err
+	}
+	return rj.numreqs(), nil
+}
+
+func (tr ThemeRequirements) MarshalJSON() ([]byte, error) {
+	return marshalCount(THEME_REQUIREMENTS, tr.NumRequirements)
+}
+
+func (tr *ThemeRequirements) UnmarshalJSON(data []byte) (err error) {
+	tr.NumRequirements, err = unmarshalCount(data)
+	return err
+}
+
+func (ur UnrestrictedRequirements) MarshalJSON() ([]byte, error) {
+	return marshalCount(UNRESTRICTED_REQUIREMENTS, ur.NumRequirements)
+}
+
+func (ur *UnrestrictedRequirements) UnmarshalJSON(data []byte) (err error) {
+	ur.NumRequirements, err = unmarshalCount(data)
+	return err
+}
+
+func (ur UnknownRequirements) MarshalJSON() ([]byte, error) {
+	return marshalCount(UNKNOWN_REQUIREMENTS, ur.NumRequirements)
+}
+
+func (ur *UnknownRequirements) UnmarshalJSON(data []byte) (err error) {
+	ur.NumRequirements, err = unmarshalCount(data)
+	return err
+}
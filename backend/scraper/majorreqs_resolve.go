@@ -0,0 +1,151 @@
+package scraper
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const CORE_ENGINEERING = "core engineering"
+
+// a requirement block together with the program it came from, Replaces names
+// the program whose block of the same kind was dropped in favour of this one
+type ResolvedBlock struct {
+	Req
+	Source   string
+	Replaces string
+}
+
+type ResolvedRequirements struct {
+	IsEngineering   bool            `json:"isEngineering"`
+	Major           string          `json:"major"`
+	CatalogYear     int             `json:"catalogYear,omitempty"`
+	AllRequirements []ResolvedBlock `json:"allreqs"`
+}
+
+// blocks are written like the plain requirement blocks with the provenance fields added
+func (rb ResolvedBlock) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(rb.Req)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["source"] = rb.Source
+	if rb.Replaces != "" {
+		fields["replaces"] = rb.Replaces
+	}
+	return json.Marshal(fields)
+}
+
+func (rr *ResolvedRequirements) MajorRequirements() *MajorRequirements {
+	mr := &MajorRequirements{
+		IsEngineering:   rr.IsEngineering,
+		Major:           rr.Major,
+		CatalogYear:     rr.CatalogYear,
+		AllRequirements: make([]Req, 0, len(rr.AllRequirements)),
+	}
+	for _, block := range rr.AllRequirements {
+		mr.AllRequirements = append(mr.AllRequirements, block.Req)
+	}
+	return mr
+}
+
+// blocks overlap when they have the same kind and, for generic blocks, the same
+// name ignoring case, punctuation and plurals ("Basic Science" and "Basic Sciences")
+func blockKey(req Req) string {
+	gr, ok := req.(GenericRequirements)
+	if !ok {
+		return "type:" + strconv.Itoa(req.GetType())
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(gr.Name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		if len(word) > 3 {
+			words[i] = strings.TrimSuffix(word, "s")
+		}
+	}
+	return "generic:" + strings.Join(words, " ")
+}
+
+// the core of the same catalog year, or of the closest earlier year that has one
+func (mrs *MajorRequirementsStore) coreEngineering(year int) *MajorRequirements {
+	for _, y := range mrs.Years {
+		if y > year {
+			continue
+		}
+		if core, ok := mrs.RequirementsByYear[y][CORE_ENGINEERING]; ok {
+			return core
+		}
+	}
+	if core, ok := mrs.RequirementsByMajor[CORE_ENGINEERING]; ok {
+		return core
+	}
+	return &CORE_ENGINEERING_REQUIREMENTS
+}
+
+// GetResolvedRequirements merges the core engineering requirements into engineering
+// majors, where both define the same block the major's version wins
+func (mrs *MajorRequirementsStore) GetResolvedRequirements(major string) (*ResolvedRequirements, bool) {
+	return mrs.GetResolvedRequirementsForYear(major, 0)
+}
+
+func (mrs *MajorRequirementsStore) GetResolvedRequirementsForYear(major string, year int) (*ResolvedRequirements, bool) {
+	reqs, ok := mrs.GetRequirementsForYear(major, year)
+	if !ok {
+		return nil, false
+	}
+
+	resolved := &ResolvedRequirements{
+		IsEngineering:   reqs.IsEngineering,
+		Major:           reqs.Major,
+		CatalogYear:     reqs.CatalogYear,
+		AllRequirements: make([]ResolvedBlock, 0, len(reqs.AllRequirements)),
+	}
+
+	core := mrs.coreEngineering(reqs.CatalogYear)
+	if !reqs.IsEngineering || strings.EqualFold(reqs.Major, core.Major) {
+		for _, req := range reqs.AllRequirements {
+			resolved.AllRequirements = append(resolved.AllRequirements, ResolvedBlock{Req: req, Source: reqs.Major})
+		}
+		return resolved, true
+	}
+
+	// unknown blocks are leftovers of the scraper and never stand in for each other
+	overrides := make(map[string]int)
+	for i, req := range reqs.AllRequirements {
+		if req.GetType() == UNKNOWN_REQUIREMENTS {
+			continue
+		}
+		if _, exists := overrides[blockKey(req)]; !exists {
+			overrides[blockKey(req)] = i
+		}
+	}
+
+	replaced := make(map[int]bool)
+	for _, req := range core.AllRequirements {
+		i, ok := overrides[blockKey(req)]
+		if !ok || req.GetType() == UNKNOWN_REQUIREMENTS {
+			resolved.AllRequirements = append(resolved.AllRequirements, ResolvedBlock{Req: req, Source: core.Major})
+			continue
+		}
+		if !replaced[i] {
+			resolved.AllRequirements = append(resolved.AllRequirements, ResolvedBlock{Req: reqs.AllRequirements[i], Source: reqs.Major, Replaces: core.Major})
+			replaced[i] = true
+		}
+	}
+
+	for i, req := range reqs.AllRequirements {
+		if !replaced[i] {
+			resolved.AllRequirements = append(resolved.AllRequirements, ResolvedBlock{Req: req, Source: reqs.Major})
+		}
+	}
+
+	return resolved, true
+}
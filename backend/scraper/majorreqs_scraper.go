@@ -22,6 +22,14 @@ type Requirements interface {
 	IsUnrestricted() bool
 }
 
+const (
+	GENERIC_REQUIREMENTS = iota
+	THEME_REQUIREMENTS
+	UNRESTRICTED_REQUIREMENTS
+	UNKNOWN_REQUIREMENTS
+)
+
+// sum type over the requirement blocks, callers type switch on the concrete struct
 type Req interface {
 	GetType() int
 }
@@ -36,38 +44,39 @@ type Option struct {
 }
 
 type GenericRequirements struct {
-	RequirementType int      `json:"requirementType"`
-	Name            string   `json:"name"`
-	Requirements    []Option `json:"requirements"`
+	Name         string
+	Requirements []Option
 }
 
-// these need better discrimination?
 type ThemeRequirements struct {
-	RequirementType int `json:"requirementType"`
-	NumRequirements int `json:"numreqs"`
+	NumRequirements int
 }
 type UnrestrictedRequirements struct {
-	RequirementType int `json:"requirementType"`
-	NumRequirements int `json:"numreqs"`
+	NumRequirements int
 }
 type UnknownRequirements struct {
-	RequirementType int `json:"requirementType"`
-	NumRequirements int `json:"numreqs"`
+	NumRequirements int
 }
 
+func (GenericRequirements) GetType() int      { return GENERIC_REQUIREMENTS }
+func (ThemeRequirements) GetType() int        { return THEME_REQUIREMENTS }
+func (UnrestrictedRequirements) GetType() int { return UNRESTRICTED_REQUIREMENTS }
+func (UnknownRequirements) GetType() int      { return UNKNOWN_REQUIREMENTS }
+
 type MajorRequirements struct {
-	IsEngineering   bool   `json:"isEngineering"`
-	Major           string `json:"major"`
-	AllRequirements []any  `json:"allreqs"`
+	IsEngineering bool   `json:"isEngineering"`
+	Major         string `json:"major"`
+	// first year of the catalog the requirements come from, 2024 for 2024-2025
+	CatalogYear     int   `json:"catalogYear,omitempty"`
+	AllRequirements []Req `json:"allreqs"`
 }
 
 var CORE_ENGINEERING_REQUIREMENTS = MajorRequirements{
 	Major:         "Core Engineering",
 	IsEngineering: true,
-	AllRequirements: []any{
+	AllRequirements: []Req{
 		GenericRequirements{
-			Name:            "Mathematics",
-			RequirementType: 0,
+			Name: "Mathematics",
 			Requirements: []Option{
 				{Between: []Requirement{Requirement{[]string{"MATH 220-1"}}}},
 				{Between: []Requirement{Requirement{[]string{"MATH 220-2"}}}},
@@ -76,8 +85,7 @@ var CORE_ENGINEERING_REQUIREMENTS = MajorRequirements{
 			},
 		},
 		GenericRequirements{
-			Name:            "Engineering Analysis and Computer Proficiency",
-			RequirementType: 0,
+			Name: "Engineering Analysis and Computer Proficiency",
 			Requirements: []Option{
 				{Between: []Requirement{Requirement{[]string{"GEN_ENG 205-1"}}, Requirement{[]string{"GEN_ENG 206-1"}}}},
 				{Between: []Requirement{Requirement{[]string{"GEN_ENG 205-2"}}}},
@@ -86,8 +94,7 @@ var CORE_ENGINEERING_REQUIREMENTS = MajorRequirements{
 			},
 		},
 		GenericRequirements{
-			Name:            "Basic Sciences",
-			RequirementType: 0,
+			Name: "Basic Sciences",
 			Requirements: []Option{
 				// at least one of these options
 				{Between: []Requirement{
@@ -141,8 +148,7 @@ var CORE_ENGINEERING_REQUIREMENTS = MajorRequirements{
 			},
 		},
 		GenericRequirements{
-			Name:            "Design and Communication",
-			RequirementType: 0,
+			Name: "Design and Communication",
 			Requirements: []Option{
 				{Between: []Requirement{
 					Requirement{[]string{"DSGN 106-1", "DSGN 106-2"}},
@@ -161,11 +167,9 @@ var CORE_ENGINEERING_REQUIREMENTS = MajorRequirements{
 			},
 		},
 		ThemeRequirements{
-			RequirementType: 1,
 			NumRequirements: 7,
 		},
 		UnrestrictedRequirements{
-			RequirementType: 2,
 			NumRequirements: 5,
 		},
 	},
@@ -5,17 +5,28 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// catalog year of files that do not name one, the files in scraper-out were
+// scraped from the 2024-2025 catalog
+const DEFAULT_CATALOG_YEAR = 2024
+
 type MajorRequirementsStore struct {
+	// most recent catalog year of every major
 	RequirementsByMajor map[string]*MajorRequirements
-	DataPath            string
+	RequirementsByYear  map[int]map[string]*MajorRequirements
+	// catalog years newest first
+	Years    []int
+	DataPath string
 }
 
 func NewMajorRequirementsStore(dataPath string) (*MajorRequirementsStore, error) {
 	store := &MajorRequirementsStore{
 		RequirementsByMajor: make(map[string]*MajorRequirements),
+		RequirementsByYear:  make(map[int]map[string]*MajorRequirements),
 		DataPath:            dataPath,
 	}
 
@@ -27,11 +38,18 @@ func NewMajorRequirementsStore(dataPath string) (*MajorRequirementsStore, error)
 	return store, nil
 }
 
+// files are either directly in DataPath or in a directory per catalog year like
+// 2025/cs.json, a catalogYear field in the file wins over the directory name
 func (mrs *MajorRequirementsStore) LoadAllMajorRequirements() error {
 	files, err := filepath.Glob(filepath.Join(mrs.DataPath, "*.json"))
 	if err != nil {
 		return err
 	}
+	yearFiles, err := filepath.Glob(filepath.Join(mrs.DataPath, "*", "*.json"))
+	if err != nil {
+		return err
+	}
+	files = append(files, yearFiles...)
 
 	for _, file := range files {
 		reqs, err := ReadMajorreqsFromJSON(file)
@@ -39,19 +57,79 @@ func (mrs *MajorRequirementsStore) LoadAllMajorRequirements() error {
 			return fmt.Errorf("failed to load %s: %w", file, err)
 		}
 
+		if reqs.CatalogYear == 0 {
+			reqs.CatalogYear = DEFAULT_CATALOG_YEAR
+			if dir := filepath.Dir(file); filepath.Clean(dir) != filepath.Clean(mrs.DataPath) {
+				year, err := strconv.Atoi(filepath.Base(dir))
+				if err != nil {
+					return fmt.Errorf("failed to load %s: %s is not a catalog year", file, filepath.Base(dir))
+				}
+				reqs.CatalogYear = year
+			}
+		}
+
 		major := strings.ToLower(reqs.Major)
-		mrs.RequirementsByMajor[major] = reqs
+		if _, exists := mrs.RequirementsByYear[reqs.CatalogYear]; !exists {
+			mrs.RequirementsByYear[reqs.CatalogYear] = make(map[string]*MajorRequirements)
+		}
+		if _, exists := mrs.RequirementsByYear[reqs.CatalogYear][major]; exists {
+			return fmt.Errorf("failed to load %s: %s already has %d requirements", file, reqs.Major, reqs.CatalogYear)
+		}
+		mrs.RequirementsByYear[reqs.CatalogYear][major] = reqs
+
+		if latest, exists := mrs.RequirementsByMajor[major]; !exists || latest.CatalogYear < reqs.CatalogYear {
+			mrs.RequirementsByMajor[major] = reqs
+		}
 	}
 
+	mrs.UpdateYearsList()
 	return nil
 }
 
+func (mrs *MajorRequirementsStore) UpdateYearsList() {
+	mrs.Years = make([]int, 0, len(mrs.RequirementsByYear))
+	for year := range mrs.RequirementsByYear {
+		mrs.Years = append(mrs.Years, year)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(mrs.Years)))
+}
+
 func (mrs *MajorRequirementsStore) GetRequirements(major string) (*MajorRequirements, bool) {
 	reqs, ok := mrs.RequirementsByMajor[strings.ToLower(major)]
 	return reqs, ok
 }
 
-// NOTE: this one returns a complicated reqs list and this may need merging and/or filtering on the client
+// a year of 0 gives the most recent catalog year of the major
+func (mrs *MajorRequirementsStore) GetRequirementsForYear(major string, year int) (*MajorRequirements, bool) {
+	if year == 0 {
+		return mrs.GetRequirements(major)
+	}
+	reqs, ok := mrs.RequirementsByYear[year][strings.ToLower(major)]
+	return reqs, ok
+}
+
+// catalog years that have requirements for the major, newest first
+func (mrs *MajorRequirementsStore) GetMajorYears(major string) []int {
+	years := make([]int, 0)
+	for _, year := range mrs.Years {
+		if _, ok := mrs.RequirementsByYear[year][strings.ToLower(major)]; ok {
+			years = append(years, year)
+		}
+	}
+	return years
+}
+
+func parseYear(r *http.Request, param string) (int, error) {
+	yearStr := r.URL.Query().Get(param)
+	if yearStr == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(yearStr)
+}
+
+// NOTE: the raw reqs list leaves merging in the core to the client, pass resolved=true to get it merged,
+// year picks the catalog year and defaults to the most recent one
 func GetMajorRequirementsHandler(store *MajorRequirementsStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		major := r.URL.Query().Get("major")
@@ -60,7 +138,33 @@ func GetMajorRequirementsHandler(store *MajorRequirementsStore) http.HandlerFunc
 			return
 		}
 
-		reqs, found := store.GetRequirements(major)
+		year, err := parseYear(r, "year")
+		if err != nil {
+			http.Error(w, "Invalid year format", http.StatusBadRequest)
+			return
+		}
+
+		if resolvedStr := r.URL.Query().Get("resolved"); resolvedStr != "" {
+			resolved, err := strconv.ParseBool(resolvedStr)
+			if err != nil {
+				http.Error(w, "Invalid resolved format", http.StatusBadRequest)
+				return
+			}
+
+			if resolved {
+				reqs, found := store.GetResolvedRequirementsForYear(major, year)
+				if !found {
+					http.Error(w, "Major not found", http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(reqs)
+				return
+			}
+		}
+
+		reqs, found := store.GetRequirementsForYear(major, year)
 		if !found {
 			http.Error(w, "Major not found", http.StatusNotFound)
 			return
@@ -81,3 +185,20 @@ func GetAvailableMajorsHandler(store *MajorRequirementsStore) http.HandlerFunc {
 		json.NewEncoder(w).Encode(majors)
 	}
 }
+
+// catalog years of a major, or of every major when none is given
+func GetCatalogYearsHandler(store *MajorRequirementsStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		years := store.Years
+		if major := r.URL.Query().Get("major"); major != "" {
+			years = store.GetMajorYears(major)
+			if len(years) == 0 {
+				http.Error(w, "Major not found", http.StatusNotFound)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(years)
+	}
+}
@@ -0,0 +1,316 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+const (
+	ISSUE_UNREADABLE       = "unreadable"
+	ISSUE_MALFORMED        = "malformed"
+	ISSUE_MALFORMED_CODE   = "malformed_code"
+	ISSUE_UNKNOWN_COURSE   = "unknown_course"
+	ISSUE_DUPLICATE_OPTION = "duplicate_option"
+	ISSUE_DUPLICATE_COURSE = "duplicate_course"
+	ISSUE_EMPTY_BETWEEN    = "empty_between"
+	ISSUE_EMPTY_BLOCK      = "empty_block"
+	ISSUE_UNKNOWN_TYPE     = "unknown_type"
+)
+
+// Block and Option are indexes into allreqs and the block's requirements, Option
+// is -1 when the issue is about the whole block
+type ValidationIssue struct {
+	Severity   string `json:"severity"`
+	Code       string `json:"code"`
+	File       string `json:"file,omitempty"`
+	Major      string `json:"major"`
+	Block      int    `json:"block"`
+	BlockName  string `json:"blockName,omitempty"`
+	Option     int    `json:"option"`
+	Course     string `json:"course,omitempty"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+type ValidationReport struct {
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Issues   []ValidationIssue `json:"issues"`
+}
+
+// course keys the way GetCourseKey writes them, "COMP_SCI 211-0"
+var courseKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]* [0-9]{3}(-[A-Z0-9]+)?$`)
+
+func (vr *ValidationReport) add(issue ValidationIssue) {
+	if issue.Severity == SEVERITY_ERROR {
+		vr.Errors++
+	} else {
+		vr.Warnings++
+	}
+	vr.Issues = append(vr.Issues, issue)
+}
+
+func (vr *ValidationReport) merge(other *ValidationReport) {
+	for _, issue := range other.Issues {
+		vr.add(issue)
+	}
+}
+
+// best effort rewrite of a malformed code like "comp sci 211" or "COMP_SCI 211-0-20"
+func canonicalCourseKey(code string) string {
+	fields := strings.Fields(strings.ToUpper(code))
+	if len(fields) < 2 {
+		return ""
+	}
+
+	number := fields[len(fields)-1]
+	if parts := strings.Split(number, "-"); len(parts) > 2 {
+		number = parts[0] + "-" + parts[1]
+	}
+
+	key := strings.Join(fields[:len(fields)-1], "_") + " " + number
+	if !courseKeyPattern.MatchString(key) {
+		return ""
+	}
+	return key
+}
+
+// catalog keys of the same subject and base number, "COMP_SCI 211" matches "COMP_SCI 211-0"
+func (cs *CoursesStore) similarKeys(key string) []string {
+	subject, number, _ := strings.Cut(key, " ")
+	base, _, _ := strings.Cut(number, "-")
+
+	keys := make([]string, 0)
+	for candidate := range cs.CoursesBySubject[subject] {
+		_, n, _ := strings.Cut(candidate, " ")
+		if b, _, _ := strings.Cut(n, "-"); b == base && candidate != key {
+			keys = append(keys, candidate)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (cs *CoursesStore) checkCourse(code string) (issue ValidationIssue, ok bool) {
+	key := code
+	if !courseKeyPattern.MatchString(code) {
+		issue = ValidationIssue{
+			Severity: SEVERITY_ERROR,
+			Code:     ISSUE_MALFORMED_CODE,
+			Course:   code,
+			Message:  fmt.Sprintf("%q is not a course key like \"COMP_SCI 211-0\"", code),
+		}
+		key = canonicalCourseKey(code)
+		if key == "" {
+			return issue, false
+		}
+		if cs == nil {
+			issue.Suggestion = key
+		} else if _, found := cs.CoursesByKey[key]; found {
+			issue.Suggestion = key
+		} else if similar := cs.similarKeys(key); len(similar) > 0 {
+			issue.Suggestion = similar[0]
+		} else {
+			issue.Suggestion = key
+		}
+		return issue, false
+	}
+
+	if cs == nil {
+		return issue, true
+	}
+	if _, found := cs.CoursesByKey[key]; found {
+		return issue, true
+	}
+
+	// the catalog only covers the scraped schools and quarters so a subject it lacks or
+	// a course not offered lately is only a warning, a sequence suffix that does not
+	// match the catalog's is a typo
+	issue = ValidationIssue{Severity: SEVERITY_WARNING, Code: ISSUE_UNKNOWN_COURSE, Course: code}
+	subject, number, _ := strings.Cut(key, " ")
+	if _, found := cs.CoursesBySubject[subject]; !found {
+		issue.Message = fmt.Sprintf("subject %s is not in the catalog", subject)
+		return issue, false
+	}
+
+	issue.Message = fmt.Sprintf("%s is not offered in any loaded quarter", key)
+	_, suffix, _ := strings.Cut(number, "-")
+	for _, similar := range cs.similarKeys(key) {
+		_, n, _ := strings.Cut(similar, " ")
+		_, other, _ := strings.Cut(n, "-")
+		if (suffix == "0" || suffix == "") != (other == "0" || other == "") {
+			issue.Severity = SEVERITY_ERROR
+			issue.Message = fmt.Sprintf("%s is not in the catalog but %s is", key, similar)
+			issue.Suggestion = similar
+			break
+		}
+	}
+	return issue, false
+}
+
+// options are the same when they list the same bundles of courses in any order
+func optionSignature(option Option) string {
+	bundles := make([]string, 0, len(option.Between))
+	for _, req := range option.Between {
+		courses := slices.Clone(req.Courses)
+		sort.Strings(courses)
+		bundles = append(bundles, strings.Join(courses, "+"))
+	}
+	sort.Strings(bundles)
+	return strings.Join(bundles, "|")
+}
+
+func validateGeneric(gr GenericRequirements, block int, courses *CoursesStore) *ValidationReport {
+	report := &ValidationReport{Issues: []ValidationIssue{}}
+	at := func(issue ValidationIssue, option int) {
+		issue.Block = block
+		issue.BlockName = gr.Name
+		issue.Option = option
+		report.add(issue)
+	}
+
+	if len(gr.Requirements) == 0 {
+		at(ValidationIssue{Severity: SEVERITY_ERROR, Code: ISSUE_EMPTY_BLOCK, Message: "block has no requirements"}, -1)
+	}
+
+	seen := make(map[string]int)
+	for i, option := range gr.Requirements {
+		if len(option.Between) == 0 {
+			at(ValidationIssue{Severity: SEVERITY_ERROR, Code: ISSUE_EMPTY_BETWEEN, Message: "option has nothing to choose between"}, i)
+			continue
+		}
+
+		sig := optionSignature(option)
+		if first, dup := seen[sig]; dup {
+			at(ValidationIssue{
+				Severity: SEVERITY_ERROR,
+				Code:     ISSUE_DUPLICATE_OPTION,
+				Message:  fmt.Sprintf("option is the same as option %d", first),
+			}, i)
+		} else {
+			seen[sig] = i
+		}
+
+		bundles := make(map[string]bool)
+		for _, req := range option.Between {
+			if len(req.Courses) == 0 {
+				at(ValidationIssue{Severity: SEVERITY_ERROR, Code: ISSUE_EMPTY_BETWEEN, Message: "option has an alternative without courses"}, i)
+				continue
+			}
+
+			bundle := strings.Join(req.Courses, "+")
+			if bundles[bundle] {
+				at(ValidationIssue{
+					Severity: SEVERITY_WARNING,
+					Code:     ISSUE_DUPLICATE_OPTION,
+					Course:   bundle,
+					Message:  "option lists the same alternative twice",
+				}, i)
+			}
+			bundles[bundle] = true
+
+			for j, code := range req.Courses {
+				if slices.Contains(req.Courses[:j], code) {
+					at(ValidationIssue{
+						Severity: SEVERITY_WARNING,
+						Code:     ISSUE_DUPLICATE_COURSE,
+						Course:   code,
+						Message:  "course is listed twice in the same alternative",
+					}, i)
+					continue
+				}
+				if issue, ok := courses.checkCourse(code); !ok {
+					at(issue, i)
+				}
+			}
+		}
+	}
+	return report
+}
+
+// ValidateMajorRequirements checks one major against the course catalog, courses
+// may be nil to only check the shape of the data
+func ValidateMajorRequirements(mr *MajorRequirements, courses *CoursesStore) *ValidationReport {
+	report := &ValidationReport{Issues: []ValidationIssue{}}
+
+	for i, req := range mr.AllRequirements {
+		switch r := req.(type) {
+		case GenericRequirements:
+			report.merge(validateGeneric(r, i, courses))
+		case UnknownRequirements:
+			report.add(ValidationIssue{
+				Severity: SEVERITY_ERROR,
+				Code:     ISSUE_UNKNOWN_TYPE,
+				Block:    i,
+				Option:   -1,
+				Message:  "block could not be classified by the scraper and cannot be audited",
+			})
+		}
+	}
+
+	for i := range report.Issues {
+		report.Issues[i].Major = mr.Major
+	}
+	return report
+}
+
+// ValidateMajorRequirementsFile also reports blocks whose type is not one of the
+// known requirement types and blocks that are not valid json, either keeps the whole
+// file from loading into the store
+func ValidateMajorRequirementsFile(file string, courses *CoursesStore) *ValidationReport {
+	report := &ValidationReport{Issues: []ValidationIssue{}}
+	unreadable := func(block int, err error) *ValidationReport {
+		code := ISSUE_UNREADABLE
+		switch {
+		case block >= 0 && errors.Is(err, ErrUnknownType):
+			code = ISSUE_UNKNOWN_TYPE
+		case block >= 0:
+			code = ISSUE_MALFORMED
+		}
+		report.add(ValidationIssue{Severity: SEVERITY_ERROR, Code: code, File: file, Block: block, Option: -1, Message: err.Error()})
+		return report
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return unreadable(-1, err)
+	}
+
+	var raw struct {
+		IsEngineering   bool              `json:"isEngineering"`
+		Major           string            `json:"major"`
+		AllRequirements []json.RawMessage `json:"allreqs"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return unreadable(-1, err)
+	}
+
+	mr := &MajorRequirements{IsEngineering: raw.IsEngineering, Major: raw.Major, AllRequirements: make([]Req, 0, len(raw.AllRequirements))}
+	for i, block := range raw.AllRequirements {
+		req, err := UnmarshalReq(block)
+		if err != nil {
+			unreadable(i, err)
+			// keep block indexes lined up with the file
+			req = UnrestrictedRequirements{}
+		}
+		mr.AllRequirements = append(mr.AllRequirements, req)
+	}
+
+	report.merge(ValidateMajorRequirements(mr, courses))
+	for i := range report.Issues {
+		report.Issues[i].File = file
+		report.Issues[i].Major = raw.Major
+	}
+	return report
+}
@@ -0,0 +1,40 @@
+package scraper
+
+import (
+	"cmp"
+	"math"
+	"strings"
+
+	"github.com/nynniaw12/ieee-planner/term"
+)
+
+var QUARTER_SORTS = map[string]func(a, b term.Info) int{
+	"code": func(a, b term.Info) int { return cmp.Compare(a.Code, b.Code) },
+}
+
+// sections order by course key, title, class number, quarter or their earliest meeting,
+// TBA sections come after every scheduled one
+var SECTION_SORTS = map[string]func(a, b *Course) int{
+	"key":     func(a, b *Course) int { return cmp.Compare(GetCourseKey(*a), GetCourseKey(*b)) },
+	"title":   func(a, b *Course) int { return cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)) },
+	"section": func(a, b *Course) int { return cmp.Compare(a.Section, b.Section) },
+	"quarter": func(a, b *Course) int { return cmp.Compare(a.Quarter, b.Quarter) },
+	"start":   func(a, b *Course) int { return cmp.Compare(earliestStart(a), earliestStart(b)) },
+}
+
+var SUBJECT_SORTS = map[string]func(a, b *CourseBySubject) int{
+	"number": func(a, b *CourseBySubject) int { return cmp.Compare(a.Number, b.Number) },
+	"title": func(a, b *CourseBySubject) int {
+		return cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	},
+}
+
+func earliestStart(course *Course) int {
+	earliest := math.MaxInt
+	for _, mt := range course.MeetingTimes {
+		if !mt.StartTime.IsZero() {
+			earliest = min(earliest, mt.StartTime.Hour()*60+mt.StartTime.Minute())
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,228 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	WARNING_CYCLE    = "cycle"
+	WARNING_DANGLING = "dangling"
+)
+
+// Courses lists the courses in a cycle, or the course and the missing key it references
+type GraphWarning struct {
+	Kind    string   `json:"kind"`
+	Courses []string `json:"courses"`
+	Message string   `json:"message"`
+}
+
+// PrereqGraph has an edge from every course to each course named in its
+// prerequisites, corequisites are left out since they point both ways
+type PrereqGraph struct {
+	Requisites map[string]*Requisites
+	Prereqs    map[string][]string
+	Unlocks    map[string][]string
+	Warnings   []GraphWarning
+}
+
+type PrereqsResponse struct {
+	Course     string         `json:"course"`
+	Requisites *Requisites    `json:"requisites"`
+	Direct     []string       `json:"direct"`
+	Transitive []string       `json:"transitive"`
+	Unlocks    []string       `json:"unlocks"`
+	Warnings   []GraphWarning `json:"warnings"`
+}
+
+// sections of a course can word their prerequisites differently, the most recent
+// quarter that has any wins
+func latestRequisites(sections []*Course) *Requisites {
+	var latest *Course
+	for _, course := range sections {
+		if course.Requisites == nil || course.Requisites.Prerequisites == nil && course.Requisites.Corequisites == nil && len(course.Requisites.Sections) == 0 {
+			continue
+		}
+		if latest == nil || course.Quarter > latest.Quarter {
+			latest = course
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return latest.Requisites
+}
+
+func (cs *CoursesStore) BuildPrereqGraph() {
+	g := &PrereqGraph{
+		Requisites: make(map[string]*Requisites),
+		Prereqs:    make(map[string][]string),
+		Unlocks:    make(map[string][]string),
+		Warnings:   []GraphWarning{},
+	}
+
+	keys := make([]string, 0, len(cs.CoursesByKey))
+	for key := range cs.CoursesByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		reqs := latestRequisites(cs.CoursesByKey[key])
+		if reqs == nil {
+			continue
+		}
+		g.Requisites[key] = reqs
+
+		for _, prereq := range reqs.Prerequisites.Courses() {
+			g.Prereqs[key] = append(g.Prereqs[key], prereq)
+			g.Unlocks[prereq] = append(g.Unlocks[prereq], key)
+
+			if _, exists := cs.CoursesByKey[prereq]; !exists {
+				g.Warnings = append(g.Warnings, GraphWarning{
+					Kind:    WARNING_DANGLING,
+					Courses: []string{key, prereq},
+					Message: fmt.Sprintf("%s requires %s which is not in the catalog", key, prereq),
+				})
+			}
+		}
+	}
+
+	for _, cycle := range g.cycles(keys) {
+		g.Warnings = append(g.Warnings, GraphWarning{
+			Kind:    WARNING_CYCLE,
+			Courses: cycle,
+			Message: fmt.Sprintf("%s require each other", strings.Join(cycle, ", ")),
+		})
+	}
+
+	cs.PrereqGraph = g
+}
+
+// strongly connected components with more than one course, or a course that
+// requires itself, found with Tarjan's algorithm
+func (g *PrereqGraph) cycles(keys []string) [][]string {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	cycles := make([][]string, 0)
+
+	var visit func(key string)
+	visit = func(key string) {
+		index[key] = len(index)
+		low[key] = index[key]
+		stack = append(stack, key)
+		onStack[key] = true
+
+		for _, next := range g.Prereqs[key] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				low[key] = min(low[key], low[next])
+			} else if onStack[next] {
+				low[key] = min(low[key], index[next])
+			}
+		}
+
+		if low[key] != index[key] {
+			return
+		}
+
+		component := make([]string, 0)
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == key {
+				break
+			}
+		}
+		if len(component) > 1 || slices.Contains(g.Prereqs[key], key) {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, key := range keys {
+		if _, seen := index[key]; !seen {
+			visit(key)
+		}
+	}
+	return cycles
+}
+
+// every course reachable through prerequisites, cycles are walked once
+func (g *PrereqGraph) TransitivePrereqs(key string) []string {
+	seen := map[string]bool{key: true}
+	queue := slices.Clone(g.Prereqs[key])
+	result := make([]string, 0)
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		result = append(result, next)
+		queue = append(queue, g.Prereqs[next]...)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// warnings about the course or anything it transitively depends on, a dangling
+// reference belongs to the course that makes it
+func (g *PrereqGraph) warningsFor(key string, transitive []string) []GraphWarning {
+	related := func(c string) bool { return c == key || slices.Contains(transitive, c) }
+
+	warnings := make([]GraphWarning, 0)
+	for _, warning := range g.Warnings {
+		if warning.Kind == WARNING_DANGLING && related(warning.Courses[0]) ||
+			warning.Kind == WARNING_CYCLE && slices.ContainsFunc(warning.Courses, related) {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+func GetPrereqsHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resolved, ok := resolveKeyParam(store, w, r)
+		if !ok {
+			return
+		}
+
+		key := resolved.Key
+		if key == "" {
+			key = resolved.Guess
+		}
+
+		g := store.PrereqGraph
+		_, offered := store.CoursesByKey[key]
+		_, required := g.Unlocks[key]
+		if !offered && !required {
+			http.Error(w, "Course not found", http.StatusNotFound)
+			return
+		}
+
+		transitive := g.TransitivePrereqs(key)
+		res := PrereqsResponse{
+			Course:     key,
+			Requisites: g.Requisites[key],
+			Direct:     append([]string{}, g.Prereqs[key]...),
+			Transitive: transitive,
+			Unlocks:    append([]string{}, g.Unlocks[key]...),
+			Warnings:   g.warningsFor(key, transitive),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
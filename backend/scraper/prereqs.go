@@ -0,0 +1,727 @@
+package scraper
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	PREREQ_COURSE = iota
+	PREREQ_AND
+	PREREQ_OR
+	// consent of the instructor or department
+	PREREQ_CONSENT
+	// anything that is not a course like placement exams or AP scores
+	PREREQ_OTHER
+)
+
+const (
+	// consent is needed no matter which courses were taken
+	CONSENT_REQUIRED = "required"
+	// consent can stand in for some of the prerequisites
+	CONSENT_ALTERNATIVE = "alternative"
+)
+
+// PrereqExpr is a tree of and/or nodes over courses, Text holds the original
+// wording of consent and other leaves
+type PrereqExpr struct {
+	Type     int           `json:"type"`
+	Course   string        `json:"course,omitempty"`
+	Text     string        `json:"text,omitempty"`
+	Children []*PrereqExpr `json:"children,omitempty"`
+	// the course may also be taken in the same quarter
+	Concurrent bool `json:"concurrent,omitempty"`
+}
+
+// Requisites are parsed from the "Prerequisite: ..." clauses of a course overview,
+// Text keeps the clauses they were parsed from
+type Requisites struct {
+	Prerequisites *PrereqExpr `json:"prerequisites,omitempty"`
+	Corequisites  *PrereqExpr `json:"corequisites,omitempty"`
+	Consent       string      `json:"consent,omitempty"`
+	// prerequisites of only some sections, they are not part of Prerequisites
+	Sections []SectionRequisites `json:"sections,omitempty"`
+	Text     []string            `json:"text"`
+}
+
+// SectionRequisites come from clauses like "Prerequisite for Portuguese discussion
+// section: PORT 201-0", For is the part naming the sections, Prerequisites is nil
+// when they have none
+type SectionRequisites struct {
+	For           string      `json:"for"`
+	Prerequisites *PrereqExpr `json:"prerequisites,omitempty"`
+}
+
+// Satisfied evaluates the expression, leaf decides for every course, consent and other leaf
+func (e *PrereqExpr) Satisfied(leaf func(*PrereqExpr) bool) bool {
+	if e == nil {
+		return true
+	}
+	switch e.Type {
+	case PREREQ_AND:
+		for _, child := range e.Children {
+			if !child.Satisfied(leaf) {
+				return false
+			}
+		}
+		return true
+	case PREREQ_OR:
+		for _, child := range e.Children {
+			if child.Satisfied(leaf) {
+				return true
+			}
+		}
+		return false
+	default:
+		return leaf(e)
+	}
+}
+
+// Missing is the smallest set of leaves that would still have to be satisfied, an
+// or node picks the child missing the fewest
+func (e *PrereqExpr) Missing(leaf func(*PrereqExpr) bool) []*PrereqExpr {
+	missing := make([]*PrereqExpr, 0)
+	if e == nil {
+		return missing
+	}
+	switch e.Type {
+	case PREREQ_AND:
+		for _, child := range e.Children {
+			missing = append(missing, child.Missing(leaf)...)
+		}
+	case PREREQ_OR:
+		for i, child := range e.Children {
+			if m := child.Missing(leaf); i == 0 || len(m) < len(missing) {
+				missing = m
+			}
+		}
+	default:
+		if !leaf(e) {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// every course key mentioned in the expression, sorted
+func (e *PrereqExpr) Courses() []string {
+	courses := make([]string, 0)
+	var walk func(*PrereqExpr)
+	walk = func(e *PrereqExpr) {
+		if e == nil {
+			return
+		}
+		if e.Type == PREREQ_COURSE && !slices.Contains(courses, e.Course) {
+			courses = append(courses, e.Course)
+		}
+		for _, child := range e.Children {
+			walk(child)
+		}
+	}
+	walk(e)
+	sort.Strings(courses)
+	return courses
+}
+
+func (e *PrereqExpr) String() string {
+	if e == nil {
+		return ""
+	}
+	switch e.Type {
+	case PREREQ_COURSE:
+		if e.Concurrent {
+			return e.Course + "*"
+		}
+		return e.Course
+	case PREREQ_AND, PREREQ_OR:
+		sep := " and "
+		if e.Type == PREREQ_OR {
+			sep = " or "
+		}
+		parts := make([]string, 0, len(e.Children))
+		for _, child := range e.Children {
+			if child.Type == PREREQ_AND || child.Type == PREREQ_OR {
+				parts = append(parts, "("+child.String()+")")
+			} else {
+				parts = append(parts, child.String())
+			}
+		}
+		return strings.Join(parts, sep)
+	case PREREQ_CONSENT:
+		return "consent[" + e.Text + "]"
+	default:
+		return "other[" + e.Text + "]"
+	}
+}
+
+var (
+	prereqHeader = regexp.MustCompile(`(?i)\bprerequisites?\b(\s*\(([^)]*)\))?(\s+or\s+co-?requisites?)?(\s+for\s+[^:.]*)?\s*:[\s:]*`)
+	coreqHeader  = regexp.MustCompile(`(?i)\bco-?requisites?\s*:[\s:]*`)
+	// "Must be taken concurrently with CHEM 217-1", "enrolled concurrently in Physics 136-2"
+	concurrentWith = regexp.MustCompile(`(?i)\bconcurrently\s+(?:in|with)\s+(?:the\s+)?([A-Za-z_]+(?: [A-Z]{2,})*\s*\d{3}(?:-\d+)?)`)
+	consentClause  = regexp.MustCompile(`(?i)\b(consent|permission)\b[^.]{0,40}?\b(instructors?|professor|department|only|number|required)\b`)
+	// "have taken or be enrolled in" style wording that allows taking the course concurrently
+	enrolledOrTaken = regexp.MustCompile(`(?i)(currently\s+)?(be\s+)?enrolled\s+in\s+or\s+(who\s+)?have\s+(taken|completed|passed)(\s+and\s+passed)?`)
+	formerOrEquiv   = regexp.MustCompile(`(?i)\(\s*former\s*\)|\bor\s+equivalent\b`)
+	courseRef       = regexp.MustCompile(`^(?:([A-Z][A-Za-z]*(?:_[A-Z]+)?(?: [A-Z]{2,}(?:_[A-Z]+)?)*) ?)?(\d{3})(?:-(\d{1,2}))?\b`)
+	courseAnywhere  = regexp.MustCompile(`\d{3}(?:-\d{1,2})?\b`)
+)
+
+// ParseRequisites extracts the requisites from a course overview, subject is the
+// course's own subject used for shorthand like "Prerequisite: 121-1", nil when
+// the overview does not mention any
+func ParseRequisites(overview, subject string) *Requisites {
+	text := strings.NewReplacer("‐", "-", "–", "-", "—", "-", " ", " ").Replace(overview)
+	req := &Requisites{Text: []string{}}
+	var prereqs, coreqs []*PrereqExpr
+
+	covered := make([][2]int, 0)
+	for _, m := range prereqHeader.FindAllStringSubmatchIndex(text, -1) {
+		end := clauseEnd(text, m[1])
+		covered = append(covered, [2]int{m[0], end})
+		clause := strings.TrimSpace(text[m[0]:end])
+		req.Text = append(req.Text, clause)
+
+		// requirements for some sections like "Prerequisite for English discussion section: none"
+		if m[8] >= 0 {
+			req.Sections = append(req.Sections, SectionRequisites{
+				For:           strings.TrimSpace(strings.TrimSpace(text[m[8]:m[9]])[len("for"):]),
+				Prerequisites: parseClause(text[m[1]:end], subject, m[6] >= 0),
+			})
+			continue
+		}
+
+		concurrent := m[6] >= 0 || (m[4] >= 0 && strings.Contains(strings.ToLower(text[m[4]:m[5]]), "concurren"))
+		if expr := parseClause(text[m[1]:end], subject, concurrent); expr != nil {
+			prereqs = append(prereqs, expr)
+		}
+	}
+
+	for _, m := range coreqHeader.FindAllStringIndex(text, -1) {
+		if isCovered(covered, m[0]) {
+			continue
+		}
+		end := clauseEnd(text, m[1])
+		covered = append(covered, [2]int{m[0], end})
+		req.Text = append(req.Text, strings.TrimSpace(text[m[0]:end]))
+		if expr := parseClause(text[m[1]:end], subject, false); expr != nil {
+			coreqs = append(coreqs, expr)
+		}
+	}
+
+	for _, m := range concurrentWith.FindAllStringSubmatchIndex(text, -1) {
+		if isCovered(covered, m[0]) {
+			continue
+		}
+		if expr := parseClause(text[m[2]:m[3]], subject, false); expr != nil {
+			req.Text = append(req.Text, strings.TrimSpace(text[m[0]:m[1]]))
+			coreqs = append(coreqs, expr)
+		}
+	}
+
+	for _, m := range consentClause.FindAllStringIndex(text, -1) {
+		if isCovered(covered, m[0]) {
+			continue
+		}
+		req.Text = append(req.Text, strings.TrimSpace(text[m[0]:m[1]]))
+		prereqs = append(prereqs, &PrereqExpr{Type: PREREQ_CONSENT, Text: strings.TrimSpace(text[m[0]:m[1]])})
+	}
+
+	if len(req.Text) == 0 {
+		return nil
+	}
+
+	req.Prerequisites = combine(PREREQ_AND, prereqs...)
+	req.Corequisites = combine(PREREQ_AND, coreqs...)
+	req.Consent = consentOf(req.Prerequisites)
+	return req
+}
+
+func isCovered(covered [][2]int, pos int) bool {
+	for _, span := range covered {
+		if pos >= span[0] && pos < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// a clause runs until the end of its sentence or line, periods inside parentheses
+// like "(e.g., ...)" do not end it
+func clauseEnd(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case '\n':
+			return i
+		case '.':
+			// "MATH 386-1.This is the second quarter" is missing its space
+			if depth == 0 && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n' || (text[i+1] >= 'A' && text[i+1] <= 'Z')) {
+				return i
+			}
+		}
+	}
+	return len(text)
+}
+
+// required when the prerequisites cannot be met with courses alone
+func consentOf(expr *PrereqExpr) string {
+	// Satisfied stops at the first satisfied child of an or so the tree is walked
+	hasConsent := false
+	var walk func(*PrereqExpr)
+	walk = func(e *PrereqExpr) {
+		if e == nil {
+			return
+		}
+		hasConsent = hasConsent || e.Type == PREREQ_CONSENT
+		for _, child := range e.Children {
+			walk(child)
+		}
+	}
+	walk(expr)
+	withoutConsent := expr.Satisfied(func(leaf *PrereqExpr) bool { return leaf.Type != PREREQ_CONSENT })
+
+	switch {
+	case !hasConsent:
+		return ""
+	case !withoutConsent:
+		return CONSENT_REQUIRED
+	default:
+		return CONSENT_ALTERNATIVE
+	}
+}
+
+// combine joins expressions with an operator, flattening nested nodes of the same
+// operator and dropping repeated courses
+func combine(op int, exprs ...*PrereqExpr) *PrereqExpr {
+	node := &PrereqExpr{Type: op, Children: []*PrereqExpr{}}
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+		children := []*PrereqExpr{expr}
+		if expr.Type == op {
+			children = expr.Children
+		}
+		for _, child := range children {
+			if child.Type == PREREQ_COURSE && slices.ContainsFunc(node.Children, func(c *PrereqExpr) bool {
+				return c.Type == PREREQ_COURSE && c.Course == child.Course
+			}) {
+				continue
+			}
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	switch len(node.Children) {
+	case 0:
+		return nil
+	case 1:
+		return node.Children[0]
+	}
+	return node
+}
+
+const (
+	tokCourse = iota
+	tokAnd
+	tokOr
+	tokComma
+	tokSemi
+	tokSlash
+	tokLParen
+	tokRParen
+	tokOneOf
+	tokWord
+)
+
+type prereqToken struct {
+	kind int
+	text string
+}
+
+// words that read like a subject in front of a number but never are one
+var notSubjects = map[string]bool{"AND": true, "OR": true, "OF": true, "ONE": true}
+
+func normalizeSubject(subject string) string {
+	subject = strings.ToUpper(strings.ReplaceAll(subject, " ", "_"))
+	// an alias naming several subjects is left for the key resolver to pick from
+	if aliases := SUBJECT_ALIASES[strings.ReplaceAll(subject, "_", "")]; len(aliases) == 1 {
+		return aliases[0]
+	}
+	return subject
+}
+
+type prereqLexer struct {
+	tokens      []prereqToken
+	lastSubject string
+	// courses marked "(concurrent registration in ... is acceptable)"
+	concurrent map[string]bool
+}
+
+func (l *prereqLexer) emit(kind int, text string) {
+	// runs of plain words become one phrase
+	if kind == tokWord && len(l.tokens) > 0 && l.tokens[len(l.tokens)-1].kind == tokWord {
+		l.tokens[len(l.tokens)-1].text += " " + text
+		return
+	}
+	l.tokens = append(l.tokens, prereqToken{kind: kind, text: text})
+}
+
+// a number on its own borrows the subject of the course before it, "SPANISH 200-0 or 204-0"
+func (l *prereqLexer) courseKey(subject, number, part string) string {
+	if subject != "" {
+		l.lastSubject = normalizeSubject(subject)
+	}
+	if part == "" {
+		part = "0"
+	}
+	return l.lastSubject + " " + number + "-" + part
+}
+
+func (l *prereqLexer) lex(src string) {
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == ':' || c == '.':
+			i++
+		case c == ',':
+			l.emit(tokComma, ",")
+			i++
+		case c == ';':
+			l.emit(tokSemi, ";")
+			i++
+		case c == '/':
+			l.emit(tokSlash, "/")
+			i++
+		case c == ')':
+			l.emit(tokRParen, ")")
+			i++
+		case c == '(':
+			end := strings.IndexByte(src[i:], ')')
+			if end == -1 {
+				i++
+				continue
+			}
+			inner := src[i+1 : i+end]
+			hasCourse := courseAnywhere.MatchString(inner)
+			if hasCourse && !strings.Contains(strings.ToLower(inner), "concurren") {
+				// a group like "(234 and 300)"
+				l.emit(tokLParen, "(")
+				i++
+				continue
+			}
+			if hasCourse {
+				sub := &prereqLexer{lastSubject: l.lastSubject}
+				sub.lex(inner)
+				for _, t := range sub.tokens {
+					if t.kind == tokCourse {
+						l.concurrent[t.text] = true
+					}
+				}
+			}
+			// notes like "(C- or better in both courses)"
+			i += end + 1
+		default:
+			// "; OR BIOL_SCI 302-0" must not read OR as part of the subject
+			if m := courseRef.FindStringSubmatch(src[i:]); m != nil && !notSubjects[strings.ToUpper(strings.Fields(m[1] + " x")[0])] &&
+				(m[1] != "" || l.lastSubject != "") {
+				l.emit(tokCourse, l.courseKey(m[1], m[2], m[3]))
+				i += len(m[0])
+				continue
+			}
+
+			end := strings.IndexAny(src[i:], " \t\n,;/()")
+			if end == -1 {
+				end = len(src) - i
+			}
+			word := strings.TrimRight(src[i:i+end], ".:")
+			if end == 0 {
+				end = 1
+			}
+			i += end
+
+			switch strings.ToLower(word) {
+			case "":
+			case "and":
+				l.emit(tokAnd, word)
+			case "or":
+				l.emit(tokOr, word)
+			case "one":
+				rest := strings.TrimLeft(src[i:], " ")
+				if strings.HasPrefix(strings.ToLower(rest), "of ") {
+					i = len(src) - len(rest) + 2
+					l.emit(tokOneOf, "one of")
+				} else {
+					l.emit(tokWord, word)
+				}
+			default:
+				l.emit(tokWord, word)
+			}
+		}
+	}
+}
+
+// "A or B, and C or D" joins two clauses, the comma marks a break like a semicolon
+// would when the list before it was joined by the other conjunction or there is
+// no list before it, while in "A, B, or C" and "A or B, or C" the comma only
+// separates list items
+func (l *prereqLexer) splitClauses() {
+	for i := 0; i+1 < len(l.tokens); i++ {
+		if l.tokens[i].kind != tokComma || (l.tokens[i+1].kind != tokAnd && l.tokens[i+1].kind != tokOr) {
+			continue
+		}
+
+		depth := 0
+		split := true
+	scan:
+		for j := i - 1; j >= 0; j-- {
+			switch l.tokens[j].kind {
+			case tokRParen:
+				depth++
+			case tokLParen:
+				if depth == 0 {
+					break scan
+				}
+				depth--
+			case tokSemi:
+				if depth == 0 {
+					break scan
+				}
+			case tokComma, tokOneOf:
+				if depth == 0 {
+					split = false
+					break scan
+				}
+			case tokAnd, tokOr:
+				if depth == 0 {
+					split = l.tokens[j].kind != l.tokens[i+1].kind
+					break scan
+				}
+			}
+		}
+
+		if split {
+			l.tokens[i] = prereqToken{kind: tokSemi, text: ";"}
+		}
+	}
+}
+
+type prereqParser struct {
+	tokens     []prereqToken
+	pos        int
+	concurrent bool
+	marked     map[string]bool
+}
+
+func (p *prereqParser) peek() *prereqToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *prereqParser) at(kinds ...int) bool {
+	t := p.peek()
+	return t != nil && slices.Contains(kinds, t.kind)
+}
+
+// the operator a comma stands for: ", or" is just a separator, otherwise the
+// next conjunction in the same list decides ("A, B, or C") and lists without
+// one are conjunctions ("PHYSICS 135-1, PHYSICS 135-2")
+func (p *prereqParser) commaOp() int {
+	depth := 0
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		switch p.tokens[i].kind {
+		case tokLParen:
+			depth++
+		case tokRParen:
+			if depth == 0 {
+				return PREREQ_AND
+			}
+			depth--
+		case tokSemi:
+			if depth == 0 {
+				return PREREQ_AND
+			}
+		case tokAnd:
+			if depth == 0 {
+				return PREREQ_AND
+			}
+		case tokOr:
+			if depth == 0 {
+				return PREREQ_OR
+			}
+		}
+	}
+	return PREREQ_AND
+}
+
+// consumes the operator at the current position if it is op, a comma directly
+// followed by a conjunction counts as that conjunction
+func (p *prereqParser) acceptOp(op int) bool {
+	want := tokAnd
+	if op == PREREQ_OR {
+		want = tokOr
+	}
+
+	if p.at(want) {
+		p.pos++
+		return true
+	}
+	if !p.at(tokComma) {
+		return false
+	}
+	if p.pos+1 < len(p.tokens) && (p.tokens[p.pos+1].kind == tokAnd || p.tokens[p.pos+1].kind == tokOr) {
+		if p.tokens[p.pos+1].kind != want {
+			return false
+		}
+		p.pos += 2
+		return true
+	}
+	if p.commaOp() == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// semicolons bind loosest and fold left to right, "A or B; and C or D; or consent"
+func (p *prereqParser) parseSemi() *PrereqExpr {
+	node := p.parseOr()
+	for p.at(tokSemi) {
+		p.pos++
+		op := PREREQ_AND
+		if p.at(tokOr) {
+			op = PREREQ_OR
+			p.pos++
+		} else if p.at(tokAnd) {
+			p.pos++
+		}
+		node = combine(op, node, p.parseOr())
+	}
+	return node
+}
+
+func (p *prereqParser) parseOr() *PrereqExpr {
+	node := p.parseAnd()
+	for p.acceptOp(PREREQ_OR) {
+		node = combine(PREREQ_OR, node, p.parseAnd())
+	}
+	return node
+}
+
+func (p *prereqParser) parseAnd() *PrereqExpr {
+	node := p.parseUnit()
+	for p.acceptOp(PREREQ_AND) {
+		node = combine(PREREQ_AND, node, p.parseUnit())
+	}
+	return node
+}
+
+// "one of A, B, or C" takes the whole list that follows
+func (p *prereqParser) parseOneOf() *PrereqExpr {
+	items := []*PrereqExpr{p.parseUnit()}
+	for {
+		if p.at(tokOr) {
+			p.pos++
+		} else if p.at(tokComma) {
+			p.pos++
+			if p.at(tokOr) {
+				p.pos++
+			}
+		} else {
+			break
+		}
+		items = append(items, p.parseUnit())
+	}
+	return combine(PREREQ_OR, items...)
+}
+
+// atoms written next to each other without an operator, words around courses
+// like "passed SPAN 197" are dropped and a unit of only words is a phrase
+func (p *prereqParser) parseUnit() *PrereqExpr {
+	var atoms []*PrereqExpr
+	var words []string
+
+loop:
+	for t := p.peek(); t != nil; t = p.peek() {
+		switch t.kind {
+		case tokCourse:
+			p.pos++
+			alternatives := []*PrereqExpr{p.course(t.text)}
+			for p.at(tokSlash) && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == tokCourse {
+				alternatives = append(alternatives, p.course(p.tokens[p.pos+1].text))
+				p.pos += 2
+			}
+			atoms = append(atoms, combine(PREREQ_OR, alternatives...))
+		case tokLParen:
+			p.pos++
+			group := p.parseSemi()
+			if p.at(tokRParen) {
+				p.pos++
+			}
+			atoms = append(atoms, group)
+		case tokOneOf:
+			p.pos++
+			atoms = append(atoms, p.parseOneOf())
+		case tokWord:
+			p.pos++
+			words = append(words, t.text)
+		case tokSlash:
+			p.pos++
+		default:
+			break loop
+		}
+	}
+
+	if len(atoms) > 0 {
+		return combine(PREREQ_AND, atoms...)
+	}
+	return phrase(strings.Join(words, " "))
+}
+
+func (p *prereqParser) course(key string) *PrereqExpr {
+	return &PrereqExpr{Type: PREREQ_COURSE, Course: key, Concurrent: p.concurrent || p.marked[key]}
+}
+
+func phrase(text string) *PrereqExpr {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+	switch {
+	case lower == "" || lower == "none" || lower == "n/a":
+		return nil
+	case strings.Contains(lower, "consent") || strings.Contains(lower, "permission"):
+		return &PrereqExpr{Type: PREREQ_CONSENT, Text: text}
+	}
+	return &PrereqExpr{Type: PREREQ_OTHER, Text: text}
+}
+
+func parseClause(clause, subject string, concurrent bool) *PrereqExpr {
+	clause = formerOrEquiv.ReplaceAllString(clause, " ")
+	if enrolledOrTaken.MatchString(clause) {
+		concurrent = true
+		clause = enrolledOrTaken.ReplaceAllString(clause, " ")
+	}
+
+	l := &prereqLexer{lastSubject: normalizeSubject(subject), concurrent: make(map[string]bool)}
+	l.lex(clause)
+	l.splitClauses()
+
+	p := &prereqParser{tokens: l.tokens, concurrent: concurrent, marked: l.concurrent}
+	expr := p.parseSemi()
+	// anything left over after an unbalanced parenthesis is still part of the clause
+	for p.pos < len(p.tokens) {
+		p.pos++
+		expr = combine(PREREQ_AND, expr, p.parseSemi())
+	}
+	return expr
+}
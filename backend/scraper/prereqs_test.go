@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+// overviews are copied from scraper-out/courses
+func TestParseRequisites(t *testing.T) {
+	type section struct {
+		For           string
+		Prerequisites string
+	}
+	tests := []struct {
+		name          string
+		course        string
+		overview      string
+		prerequisites string
+		corequisites  string
+		consent       string
+		sections      []section
+	}{
+		{
+			name:          "subject shorthand",
+			course:        "SPANISH 251-0",
+			overview:      "This course provides a foundation to further study of the Spanish cultures in more advanced courses. Prerequisite (may be taken concurrently): SPANISH 200-0 or 204-0",
+			prerequisites: "SPANISH 200-0* or SPANISH 204-0*",
+		},
+		{
+			name:          "consent after semicolon",
+			course:        "MATH 320-1",
+			overview:      "Prerequisite: MATH 226-0 or MATH 281-2; and MATH 300-0 or MATH 291-3; or consent of the department.",
+			prerequisites: "((MATH 226-0 or MATH 281-2) and (MATH 300-0 or MATH 291-3)) or consent[consent of the department]",
+			consent:       CONSENT_ALTERNATIVE,
+		},
+		{
+			name:          "enrolled in or have taken",
+			course:        "SPANISH 205-0",
+			overview:      "Advanced course to develop communication skills in Spanish for healthcare purposes. Emphasis on language skills for the medical field, specialized terminology and vocabulary, and cultural nuances. Prerequisite: Students must currently be enrolled in or have taken and passed SPAN 197/200/201, OR have an AP score of 5, OR sufficient score on the Spanish Language Placement Exam.",
+			prerequisites: "SPANISH 197-0* or SPANISH 200-0* or SPANISH 201-0* or other[have an AP score of 5] or other[sufficient score on the Spanish Language Placement Exam]",
+		},
+		{
+			name:          "cross listed",
+			course:        "SOCIOL 227-0",
+			overview:      "Prerequisite: LEGAL_ST 206/SOCIOL 206.",
+			prerequisites: "LEGAL_ST 206-0 or SOCIOL 206-0",
+		},
+		{
+			name:     "section specific",
+			course:   "PORT 210-0",
+			overview: "Includes English or Portuguese discussion sections. Prerequisite for Portuguese discussion section: PORT 201-0, PORT 202-0, or sufficient score on placement exam. Prerequisite for English discussion section: none.",
+			sections: []section{
+				{"Portuguese discussion section", "PORT 201-0 or PORT 202-0 or other[sufficient score on placement exam]"},
+				{"English discussion section", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, _, _ := strings.Cut(tt.course, " ")
+			req := ParseRequisites(tt.overview, subject)
+			if req == nil {
+				t.Fatalf("no requisites parsed from %q", tt.overview)
+			}
+			if got := req.Prerequisites.String(); got != tt.prerequisites {
+				t.Errorf("prerequisites = %q, want %q", got, tt.prerequisites)
+			}
+			if got := req.Corequisites.String(); got != tt.corequisites {
+				t.Errorf("corequisites = %q, want %q", got, tt.corequisites)
+			}
+			if req.Consent != tt.consent {
+				t.Errorf("consent = %q, want %q", req.Consent, tt.consent)
+			}
+			if len(req.Sections) != len(tt.sections) {
+				t.Fatalf("got %d section requisites, want %d", len(req.Sections), len(tt.sections))
+			}
+			for i, want := range tt.sections {
+				got := req.Sections[i]
+				if got.For != want.For || got.Prerequisites.String() != want.Prerequisites {
+					t.Errorf("section %d = %q %q, want %q %q", i, got.For, got.Prerequisites.String(), want.For, want.Prerequisites)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package scraper
+
+import (
+	"encoding/json"
+	"html"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fields of a course that are searched, a match in the title counts for more than one
+// buried in the overview
+const (
+	FIELD_SUBJECT = iota
+	FIELD_TITLE
+	FIELD_TOPIC
+	FIELD_INSTRUCTORS
+	FIELD_OVERVIEW
+	FIELD_COUNT
+)
+
+var FIELD_NAMES = []string{"subject", "title", "topic", "instructors", "overview"}
+
+var FIELD_WEIGHTS = [FIELD_COUNT]float64{3, 3, 2, 1.5, 1}
+
+// BM25 term saturation and length normalization
+const (
+	BM25_K1 = 1.2
+	BM25_B  = 0.75
+)
+
+const (
+	DEFAULT_SEARCH_LIMIT = 20
+	MAX_SEARCH_LIMIT     = 100
+	SNIPPET_TOKENS       = 30
+)
+
+// positions of the values of a multi valued field are spaced apart so a phrase never
+// runs from one value into the next
+const valueGap = 16
+
+// common words are indexed so phrases keep their positions but do not rank on their own
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+type token struct {
+	text       string
+	position   int
+	start, end int
+}
+
+type posting struct {
+	doc       int
+	field     int
+	positions []int
+}
+
+type searchDoc struct {
+	key      string
+	title    string
+	subject  string
+	quarters []int
+	text     [FIELD_COUNT]string
+	tokens   [FIELD_COUNT][]token
+}
+
+// SearchIndex is an inverted index over one document per course key, the latest
+// quarter's wording wins and topics and instructors of every section are kept
+type SearchIndex struct {
+	docs      []*searchDoc
+	postings  map[string][]posting
+	avgLength [FIELD_COUNT]float64
+}
+
+type SearchResult struct {
+	Course   string   `json:"course"`
+	Title    string   `json:"title"`
+	Subject  string   `json:"subject"`
+	Quarters []int    `json:"quarters"`
+	Score    float64  `json:"score"`
+	Matched  []string `json:"matched"`
+	Snippet  string   `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+// tokenize splits text into lowercase words, underscores stay inside words so
+// subjects like COMP_SCI are one token
+func tokenize(text string, offset int) []token {
+	tokens := make([]token, 0)
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{text: strings.ToLower(text[start:end]), position: offset + len(tokens), start: start, end: end})
+			start = -1
+		}
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// the values of a field are joined with newlines, positions restart valueGap past the last one
+func fieldTokens(values []string) (string, []token) {
+	text := strings.Join(values, "\n")
+	tokens := make([]token, 0)
+	offset, position := 0, 0
+	for _, value := range values {
+		for _, t := range tokenize(value, position) {
+			t.start += offset
+			t.end += offset
+			tokens = append(tokens, t)
+		}
+		if len(tokens) > 0 {
+			position = tokens[len(tokens)-1].position + valueGap
+		}
+		offset += len(value) + 1
+	}
+	return text, tokens
+}
+
+func (cs *CoursesStore) BuildSearchIndex() {
+	idx := &SearchIndex{postings: make(map[string][]posting)}
+
+	keys := make([]string, 0, len(cs.CoursesByKey))
+	for key := range cs.CoursesByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sections := slices.Clone(cs.CoursesByKey[key])
+		sort.SliceStable(sections, func(i, j int) bool { return sections[i].Quarter > sections[j].Quarter })
+
+		latest := sections[0]
+		doc := &searchDoc{key: key, title: latest.Title, subject: latest.Subject, quarters: []int{}}
+		var topics, instructors []string
+		overview := ""
+		for _, course := range sections {
+			if !slices.Contains(doc.quarters, course.Quarter) {
+				doc.quarters = append(doc.quarters, course.Quarter)
+			}
+			if course.Topic != "" && !slices.Contains(topics, course.Topic) {
+				topics = append(topics, course.Topic)
+			}
+			for _, instructor := range course.Instructors {
+				if instructor.Name != "" && !slices.Contains(instructors, instructor.Name) {
+					instructors = append(instructors, instructor.Name)
+				}
+			}
+			if overview == "" {
+				overview = strings.TrimSpace(course.Overview)
+			}
+		}
+		slices.Sort(doc.quarters)
+
+		values := [FIELD_COUNT][]string{
+			FIELD_SUBJECT:     {key},
+			FIELD_TITLE:       {latest.Title},
+			FIELD_TOPIC:       topics,
+			FIELD_INSTRUCTORS: instructors,
+			FIELD_OVERVIEW:    {overview},
+		}
+
+		id := len(idx.docs)
+		for field := range FIELD_COUNT {
+			doc.text[field], doc.tokens[field] = fieldTokens(values[field])
+			idx.avgLength[field] += float64(len(doc.tokens[field]))
+
+			positions := make(map[string][]int)
+			order := make([]string, 0)
+			for _, t := range doc.tokens[field] {
+				if _, seen := positions[t.text]; !seen {
+					order = append(order, t.text)
+				}
+				positions[t.text] = append(positions[t.text], t.position)
+			}
+			for _, term := range order {
+				idx.postings[term] = append(idx.postings[term], posting{doc: id, field: field, positions: positions[term]})
+			}
+		}
+		idx.docs = append(idx.docs, doc)
+	}
+
+	for field := range FIELD_COUNT {
+		if len(idx.docs) > 0 {
+			idx.avgLength[field] /= float64(len(idx.docs))
+		}
+	}
+	cs.Search = idx
+}
+
+// a query is loose terms and "quoted phrases", every phrase has to match while the
+// terms only rank
+type searchQuery struct {
+	terms   []string
+	phrases [][]string
+}
+
+func parseQuery(q string) searchQuery {
+	var query searchQuery
+	parts := strings.Split(q, `"`)
+	for i, part := range parts {
+		words := make([]string, 0)
+		for _, t := range tokenize(part, 0) {
+			words = append(words, t.text)
+		}
+		// text between an opening and closing quote
+		if i%2 == 1 && i < len(parts)-1 && len(words) > 1 {
+			query.phrases = append(query.phrases, words)
+			continue
+		}
+		query.terms = append(query.terms, words...)
+	}
+	return query
+}
+
+// every term ranked, stopwords only when the query has nothing else
+func (q searchQuery) scored() []string {
+	terms := make([]string, 0)
+	for _, term := range q.terms {
+		if !stopwords[term] && !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	for _, phrase := range q.phrases {
+		for _, term := range phrase {
+			if !stopwords[term] && !slices.Contains(terms, term) {
+				terms = append(terms, term)
+			}
+		}
+	}
+	if len(terms) == 0 {
+		for _, term := range slices.Concat(append(q.phrases, q.terms)...) {
+			if !slices.Contains(terms, term) {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
+
+// positions of a term by document and field
+func (idx *SearchIndex) positions(term string) map[int]map[int][]int {
+	positions := make(map[int]map[int][]int)
+	for _, p := range idx.postings[term] {
+		if positions[p.doc] == nil {
+			positions[p.doc] = make(map[int][]int)
+		}
+		positions[p.doc][p.field] = p.positions
+	}
+	return positions
+}
+
+// phraseFields lists the fields of a document in which the words of a phrase appear
+// one after another, positions holds the positions of every word of the phrase
+func phraseFields(doc int, positions []map[int]map[int][]int) []int {
+	fields := make([]int, 0)
+	for field, starts := range positions[0][doc] {
+	next:
+		for _, start := range starts {
+			for i := 1; i < len(positions); i++ {
+				if !slices.Contains(positions[i][doc][field], start+i) {
+					continue next
+				}
+			}
+			fields = append(fields, field)
+			break
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// BM25F, term frequencies are weighted and length normalized per field before they saturate
+func (idx *SearchIndex) score(terms []string) map[int]float64 {
+	scores := make(map[int]float64)
+	n := float64(len(idx.docs))
+	for _, term := range terms {
+		postings := idx.postings[term]
+		docs := make(map[int]float64)
+		for _, p := range postings {
+			length := float64(len(idx.docs[p.doc].tokens[p.field]))
+			norm := 1 - BM25_B
+			if idx.avgLength[p.field] > 0 {
+				norm += BM25_B * length / idx.avgLength[p.field]
+			}
+			docs[p.doc] += FIELD_WEIGHTS[p.field] * float64(len(p.positions)) / norm
+		}
+
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for doc, tf := range docs {
+			scores[doc] += idf * tf * (BM25_K1 + 1) / (tf + BM25_K1)
+		}
+	}
+	return scores
+}
+
+// snippet picks the stretch of a field with the most matching words and marks them,
+// the rest of the text is escaped so the snippet can be shown as html
+func snippet(text string, tokens []token, match func(int) bool) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	best, bestCount := 0, -1
+	for start := range tokens {
+		count := 0
+		for i := start; i < len(tokens) && i < start+SNIPPET_TOKENS; i++ {
+			if match(i) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = start, count
+		}
+		if start+SNIPPET_TOKENS >= len(tokens) {
+			break
+		}
+	}
+	// a little context before the first match
+	best = max(0, best-3)
+	last := min(len(tokens), best+SNIPPET_TOKENS) - 1
+
+	var b strings.Builder
+	if best > 0 {
+		b.WriteString("…")
+	}
+	cursor := tokens[best].start
+	for i := best; i <= last; i++ {
+		t := tokens[i]
+		b.WriteString(html.EscapeString(text[cursor:t.start]))
+		if match(i) {
+			b.WriteString("<mark>" + html.EscapeString(text[t.start:t.end]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(text[t.start:t.end]))
+		}
+		cursor = t.end
+	}
+	if last < len(tokens)-1 {
+		b.WriteString("…")
+	}
+	return strings.ReplaceAll(b.String(), "\n", " ")
+}
+
+// Search ranks the courses matching the query, quarter keeps only courses offered in it
+func (idx *SearchIndex) Search(q string, quarter int) []SearchResult {
+	query := parseQuery(q)
+	terms := query.scored()
+	scores := idx.score(terms)
+
+	phrases := make([][]map[int]map[int][]int, len(query.phrases))
+	for i, phrase := range query.phrases {
+		for _, term := range phrase {
+			phrases[i] = append(phrases[i], idx.positions(term))
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for doc, score := range scores {
+		d := idx.docs[doc]
+		if quarter != 0 && !slices.Contains(d.quarters, quarter) {
+			continue
+		}
+
+		phraseMatched := make([]int, 0)
+		missing := false
+		for _, positions := range phrases {
+			fields := phraseFields(doc, positions)
+			if len(fields) == 0 {
+				missing = true
+				break
+			}
+			for _, field := range fields {
+				score += FIELD_WEIGHTS[field]
+				if !slices.Contains(phraseMatched, field) {
+					phraseMatched = append(phraseMatched, field)
+				}
+			}
+		}
+		if missing {
+			continue
+		}
+
+		matched := make([]string, 0)
+		for field := range FIELD_COUNT {
+			if slices.Contains(phraseMatched, field) || slices.ContainsFunc(d.tokens[field], func(t token) bool { return slices.Contains(terms, t.text) }) {
+				matched = append(matched, FIELD_NAMES[field])
+			}
+		}
+
+		// the overview shows the match in context, the title when the overview has none
+		field := FIELD_OVERVIEW
+		if !slices.Contains(matched, FIELD_NAMES[FIELD_OVERVIEW]) {
+			for _, f := range []int{FIELD_TITLE, FIELD_TOPIC, FIELD_INSTRUCTORS} {
+				if slices.Contains(matched, FIELD_NAMES[f]) {
+					field = f
+					break
+				}
+			}
+		}
+		tokens := d.tokens[field]
+		text := snippet(d.text[field], tokens, func(i int) bool {
+			if slices.Contains(terms, tokens[i].text) {
+				return true
+			}
+			for _, phrase := range query.phrases {
+				if slices.Contains(phrase, tokens[i].text) && !stopwords[tokens[i].text] {
+					return true
+				}
+			}
+			return false
+		})
+
+		results = append(results, SearchResult{
+			Course:   d.key,
+			Title:    d.title,
+			Subject:  d.subject,
+			Quarters: d.quarters,
+			Score:    math.Round(score*1000) / 1000,
+			Matched:  matched,
+			Snippet:  text,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Course < results[j].Course
+	})
+	return results
+}
+
+func SearchHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			http.Error(w, "Q parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		limit := DEFAULT_SEARCH_LIMIT
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var err error
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+				http.Error(w, "Invalid limit format", http.StatusBadRequest)
+				return
+			}
+		}
+		limit = min(limit, MAX_SEARCH_LIMIT)
+
+		quarter := 0
+		if quarterStr := r.URL.Query().Get("quarter"); quarterStr != "" {
+			var err error
+			if quarter, err = strconv.Atoi(quarterStr); err != nil {
+				http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		results := store.Search.Search(q, quarter)
+		res := SearchResponse{Query: q, Total: len(results), Results: results[:min(limit, len(results))]}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
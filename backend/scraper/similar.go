@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DEFAULT_SIMILAR_LIMIT = 10
+	MAX_SIMILAR_LIMIT     = 50
+	SHARED_TERMS          = 5
+)
+
+// the overview the registrar gives courses without one, nearly half of them, would
+// otherwise make all those courses look alike
+const PLACEHOLDER_OVERVIEW = "contact the department for further information"
+
+// a word in the title or topic says more about a course than one in its overview
+var SIMILAR_FIELD_WEIGHTS = map[int]float64{FIELD_TITLE: 2, FIELD_TOPIC: 2, FIELD_OVERVIEW: 1}
+
+// SimilarityIndex holds a unit length TF-IDF vector for every course key, built from
+// the documents of the search index so both see the same wording
+type SimilarityIndex struct {
+	docs    []*searchDoc
+	byKey   map[string]int
+	vectors []map[string]float64
+}
+
+type SimilarCourse struct {
+	Course   string   `json:"course"`
+	Title    string   `json:"title"`
+	Subject  string   `json:"subject"`
+	Quarters []int    `json:"quarters"`
+	Score    float64  `json:"score"`
+	Terms    []string `json:"terms"`
+}
+
+type SimilarResponse struct {
+	Course  string          `json:"course"`
+	Quarter int             `json:"quarter,omitempty"`
+	Results []SimilarCourse `json:"results"`
+}
+
+// numbers and stopwords say nothing about what a course covers
+func similarTerm(text string) bool {
+	if stopwords[text] || len(text) < 2 {
+		return false
+	}
+	return strings.ContainsFunc(text, func(r rune) bool { return r < '0' || r > '9' })
+}
+
+func (cs *CoursesStore) BuildSimilarityIndex() {
+	idx := &SimilarityIndex{byKey: make(map[string]int)}
+	if cs.Search != nil {
+		idx.docs = cs.Search.docs
+	}
+
+	// term frequencies are damped with a log so a long overview repeating a word does
+	// not drown out the title
+	counts := make([]map[string]float64, len(idx.docs))
+	df := make(map[string]int)
+	for i, doc := range idx.docs {
+		idx.byKey[doc.key] = i
+		counts[i] = make(map[string]float64)
+		for field, weight := range SIMILAR_FIELD_WEIGHTS {
+			if field == FIELD_OVERVIEW && strings.EqualFold(strings.TrimRight(doc.text[field], "."), PLACEHOLDER_OVERVIEW) {
+				continue
+			}
+			for _, t := range doc.tokens[field] {
+				if similarTerm(t.text) {
+					counts[i][t.text] += weight
+				}
+			}
+		}
+		for term := range counts[i] {
+			df[term]++
+		}
+	}
+
+	n := float64(len(idx.docs))
+	idx.vectors = make([]map[string]float64, len(idx.docs))
+	for i, tf := range counts {
+		vector := make(map[string]float64, len(tf))
+		norm := 0.0
+		for term, count := range tf {
+			weight := (1 + math.Log(count)) * math.Log(n/float64(df[term]))
+			if weight > 0 {
+				vector[term] = weight
+				norm += weight * weight
+			}
+		}
+		norm = math.Sqrt(norm)
+		for term := range vector {
+			vector[term] /= norm
+		}
+		idx.vectors[i] = vector
+	}
+
+	cs.Similar = idx
+}
+
+// Similar ranks the other courses by cosine similarity to key, quarter keeps only
+// courses offered in it, Terms are the words contributing the most to a score
+func (idx *SimilarityIndex) Similar(key string, quarter int) ([]SimilarCourse, bool) {
+	i, found := idx.byKey[key]
+	if !found {
+		return nil, false
+	}
+	vector := idx.vectors[i]
+
+	results := make([]SimilarCourse, 0)
+	for j, other := range idx.vectors {
+		d := idx.docs[j]
+		if j == i || quarter != 0 && !slices.Contains(d.quarters, quarter) {
+			continue
+		}
+
+		score := 0.0
+		shared := make(map[string]float64)
+		for term, weight := range vector {
+			if w, found := other[term]; found {
+				score += weight * w
+				shared[term] = weight * w
+			}
+		}
+		if score <= 0 {
+			continue
+		}
+
+		terms := make([]string, 0, len(shared))
+		for term := range shared {
+			terms = append(terms, term)
+		}
+		sort.Slice(terms, func(a, b int) bool {
+			if shared[terms[a]] != shared[terms[b]] {
+				return shared[terms[a]] > shared[terms[b]]
+			}
+			return terms[a] < terms[b]
+		})
+
+		results = append(results, SimilarCourse{
+			Course:   d.key,
+			Title:    d.title,
+			Subject:  d.subject,
+			Quarters: d.quarters,
+			Score:    math.Round(score*1000) / 1000,
+			Terms:    terms[:min(SHARED_TERMS, len(terms))],
+		})
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return results[a].Course < results[b].Course
+	})
+	return results, true
+}
+
+// quarter narrows the alternatives to courses offered in it
+func GetSimilarCoursesHandler(store *CoursesStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resolved, ok := resolveKeyParam(store, w, r)
+		if !ok {
+			return
+		}
+
+		limit := DEFAULT_SIMILAR_LIMIT
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var err error
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+				http.Error(w, "Invalid limit format", http.StatusBadRequest)
+				return
+			}
+		}
+		limit = min(limit, MAX_SIMILAR_LIMIT)
+
+		quarter := 0
+		if quarterStr := r.URL.Query().Get("quarter"); quarterStr != "" {
+			var err error
+			if quarter, err = strconv.Atoi(quarterStr); err != nil {
+				http.Error(w, "Invalid quarter format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		results, found := store.Similar.Similar(resolved.Key, quarter)
+		if !found {
+			http.Error(w, "Course not found", http.StatusNotFound)
+			return
+		}
+		res := SimilarResponse{Course: resolved.Key, Quarter: quarter, Results: results[:min(limit, len(results))]}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
@@ -0,0 +1,188 @@
+package term
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// quarter codes step by 10 and cycle through the seasons starting with fall
+type Season int
+
+const (
+	FALL Season = iota
+	WINTER
+	SPRING
+	SUMMER
+)
+
+var SEASON_NAMES = []string{"Fall", "Winter", "Spring", "Summer"}
+
+// 4960 is fall 2024, years are calendar years so 4970 is winter 2025
+const (
+	BASE_CODE = 4960
+	BASE_YEAR = 2024
+)
+
+// class times and term dates are local to the campus
+const TIMEZONE = "America/Chicago"
+
+// first and last day of classes, check new terms against the registrar's academic calendar
+var DATES = map[int][2]string{
+	4960: {"2024-09-24", "2024-12-07"},
+	4970: {"2025-01-06", "2025-03-15"},
+	4980: {"2025-03-31", "2025-06-07"},
+	4990: {"2025-06-23", "2025-08-16"},
+}
+
+// terms missing from the table get the usual month and day of the start and end of their season
+var seasonDates = [4][4]int{
+	{9, 23, 12, 6},
+	{1, 6, 3, 14},
+	{3, 31, 6, 6},
+	{6, 23, 8, 15},
+}
+
+type Term struct {
+	Code   int
+	Season Season
+	Year   int
+}
+
+// Info is a term as the api returns it, Estimated is set when the dates are not in the table
+type Info struct {
+	Code      int    `json:"code"`
+	Name      string `json:"name"`
+	Season    string `json:"season"`
+	Year      int    `json:"year"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Estimated bool   `json:"estimated,omitempty"`
+}
+
+func (s Season) String() string {
+	if s < FALL || s > SUMMER {
+		return fmt.Sprintf("Season(%d)", int(s))
+	}
+	return SEASON_NAMES[s]
+}
+
+func ParseSeason(name string) (Season, error) {
+	for i, season := range SEASON_NAMES {
+		if strings.EqualFold(strings.TrimSpace(name), season) {
+			return Season(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid season %q", name)
+}
+
+// FromCode decodes a quarter code, codes that are not a multiple of 10 are invalid
+func FromCode(code int) (Term, error) {
+	if code <= 0 || code%10 != 0 {
+		return Term{}, fmt.Errorf("invalid quarter code %d", code)
+	}
+	return Of(code), nil
+}
+
+// Of decodes a quarter code that is known to be valid
+func Of(code int) Term {
+	index := code/10 - BASE_CODE/10
+	season := ((index % 4) + 4) % 4
+	year := BASE_YEAR + (index-season)/4
+	if season != int(FALL) {
+		year++
+	}
+	return Term{Code: code, Season: Season(season), Year: year}
+}
+
+func New(season Season, year int) Term {
+	years := year - BASE_YEAR
+	if season != FALL {
+		years--
+	}
+	return Of(BASE_CODE + (years*4+int(season))*10)
+}
+
+// Parse reads a quarter code like "4960" or a name like "Fall 2024" or "2024 Fall"
+func Parse(s string) (Term, error) {
+	s = strings.TrimSpace(s)
+	if code, err := strconv.Atoi(s); err == nil {
+		return FromCode(code)
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Term{}, fmt.Errorf("invalid term %q", s)
+	}
+	year, err := strconv.Atoi(fields[1])
+	name := fields[0]
+	if err != nil {
+		year, err = strconv.Atoi(fields[0])
+		name = fields[1]
+	}
+	if err != nil {
+		return Term{}, fmt.Errorf("invalid term %q", s)
+	}
+	season, err := ParseSeason(name)
+	if err != nil {
+		return Term{}, err
+	}
+	return New(season, year), nil
+}
+
+func (t Term) Name() string {
+	return fmt.Sprintf("%s %d", t.Season, t.Year)
+}
+
+func (t Term) String() string {
+	return t.Name()
+}
+
+func (t Term) Next() Term {
+	return Of(t.Code + 10)
+}
+
+func (t Term) Prev() Term {
+	return Of(t.Code - 10)
+}
+
+func location() *time.Location {
+	loc, err := time.LoadLocation(TIMEZONE)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Dates returns the first and last day of classes at midnight campus time, estimated
+// from the season when the term is not in the table
+func (t Term) Dates() (time.Time, time.Time, bool) {
+	loc := location()
+	if dates, ok := DATES[t.Code]; ok {
+		start, startErr := time.ParseInLocation(time.DateOnly, dates[0], loc)
+		end, endErr := time.ParseInLocation(time.DateOnly, dates[1], loc)
+		if startErr == nil && endErr == nil {
+			return start, end, false
+		}
+	}
+
+	d := seasonDates[t.Season]
+	start := time.Date(t.Year, time.Month(d[0]), d[1], 0, 0, 0, 0, loc)
+	end := time.Date(t.Year, time.Month(d[2]), d[3], 0, 0, 0, 0, loc)
+	return start, end, true
+}
+
+func (t Term) Info() Info {
+	start, end, estimated := t.Dates()
+	return Info{
+		Code:      t.Code,
+		Name:      t.Name(),
+		Season:    t.Season.String(),
+		Year:      t.Year,
+		Start:     start.Format(time.DateOnly),
+		End:       end.Format(time.DateOnly),
+		Estimated: estimated,
+	}
+}
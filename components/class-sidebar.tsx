@@ -76,7 +76,7 @@ export function ClassSidebar({
                 const response = await fetch(`http://localhost:8080/api/courses?quarter=${selectedQuarter}`)
                 if (!response.ok) throw new Error("Failed to fetch courses")
                 const data = await response.json()
-                setCourses(data)
+                setCourses(data.items)
             } catch (error) {
                 console.error("Error fetching courses:", error)
                 setCourses([])